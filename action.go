@@ -1,14 +1,31 @@
 package clippy
 
-import "errors"
+import (
+	"context"
+	"errors"
+)
 
 // Action represents a function run by a command.
-type Action func(flags map[string]string, args []string) error
+type Action func(flags Flags, args []string) error
+
+// ActionCtx represents a function run by a command that is given a context.
+// The context is cancelled when the program is interrupted, if it was run with RunContext.
+type ActionCtx func(ctx context.Context, flags Flags, args []string) error
+
+// action returns ctxAction with ctx bound as an Action, or action if ctxAction is nil.
+func action(ctx context.Context, action Action, ctxAction ActionCtx) Action {
+	if ctxAction == nil {
+		return action
+	}
+	return func(flags Flags, args []string) error {
+		return ctxAction(ctx, flags, args)
+	}
+}
 
 // DefaultAction is a no-op. It does nothing at all.
-var DefaultAction Action = func(flags map[string]string, args []string) error { return nil }
+var DefaultAction Action = func(flags Flags, args []string) error { return nil }
 
 // HelpAction returns an error saying to use the "--help" global flag.
-var HelpAction Action = func(flags map[string]string, args []string) error {
+var HelpAction Action = func(flags Flags, args []string) error {
 	return errors.New("use the \"--help\" global flag")
 }
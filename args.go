@@ -0,0 +1,63 @@
+package clippy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkArgs checks that no more arguments are given than are declared. A last declared argument ending in "..." accepts any number of arguments.
+func checkArgs(declared, args []string) error {
+	if n := len(declared); n >= 1 && strings.HasSuffix(declared[n-1], "...") {
+		return nil
+	}
+	if len(args) > len(declared) {
+		return fmt.Errorf("unexpected argument: %q", args[len(declared)])
+	}
+	return nil
+}
+
+// argsUsage returns the default usage of the declared arguments.
+func argsUsage(declared []string) string {
+	if len(declared) == 0 {
+		return "[arguments...]"
+	}
+	return strings.Join(declared, " ")
+}
+
+// expandUsage expands the placeholders in a hand-written usage from what is declared.
+// "{flags}" becomes each visible flag, in brackets unless it is required, "{args}" the declared arguments, and "{command}" "[command]" if there are commands.
+func expandUsage(usage string, fs FlagSet, args []string, cs CommandSet) string {
+	if !strings.Contains(usage, "{") {
+		return usage
+	}
+	command := ""
+	if len(cs.visible()) >= 1 {
+		command = "[command]"
+	}
+	expanded := strings.NewReplacer("{flags}", flagsUsage(fs), "{args}", argsUsage(args), "{command}", command).Replace(usage)
+	// Close up the gaps left by placeholders that expanded to nothing.
+	return strings.Join(strings.Fields(expanded), " ")
+}
+
+// flagsUsage returns the usage of each visible flag in fs, such as "--name" for booleans and "--name TYPE" for others.
+func flagsUsage(fs FlagSet) string {
+	var usages []string
+	for _, flag := range fs.visible() {
+		usage := "--" + flag.Name
+		if flag.Kind.takesValue() {
+			typ := flag.Type
+			if typ == "" {
+				typ = strings.ToUpper(flag.Kind.String())
+			}
+			usage += " " + typ
+		}
+		if flag.Repeatable {
+			usage += "..."
+		}
+		if !flag.Required {
+			usage = "[" + usage + "]"
+		}
+		usages = append(usages, usage)
+	}
+	return strings.Join(usages, " ")
+}
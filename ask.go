@@ -0,0 +1,72 @@
+package clippy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// askValue is the default value that asks for a flag's value, for flags with Ask set.
+const askValue = "ask"
+
+// ask prompts on stderr for the value of flag f, reading the answer from stdin.
+// It is an error if stdin is not a terminal, since there is nobody to answer, or if stdin is the command's input.
+func (c *Clippy) ask(f *Flag) (string, error) {
+	if c.readsStdin {
+		return "", fmt.Errorf("flag %q must be given since stdin is used for input", f.Name)
+	}
+	if !isTerminal(os.Stdin) {
+		return "", fmt.Errorf("flag %q must be given when not running interactively", f.Name)
+	}
+
+	for {
+		if f.Kind == BoolKind {
+			fmt.Fprintf(c.stderr(), "--%s? [y/n] ", f.Name)
+		} else {
+			fmt.Fprintf(c.stderr(), "--%s: ", f.Name)
+		}
+		answer, err := readLine(os.Stdin)
+		if err != nil {
+			return "", fmt.Errorf("cannot ask for value of flag %q: %v", f.Name, err)
+		}
+		if f.Kind != BoolKind {
+			return answer, nil
+		}
+		switch strings.ToLower(answer) {
+		case "y", "yes":
+			return "true", nil
+		case "n", "no":
+			return "false", nil
+		}
+	}
+}
+
+// isTerminal returns whether f is a terminal rather than a file or pipe.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// readLine reads a line from r without reading past it, so later reads see the rest of the input.
+func readLine(r io.Reader) (string, error) {
+	var line []byte
+	b := make([]byte, 1)
+	for {
+		n, err := r.Read(b)
+		if n == 1 {
+			if b[0] == '\n' {
+				return strings.TrimRight(string(line), "\r"), nil
+			}
+			line = append(line, b[0])
+		}
+		if err == io.EOF && len(line) >= 1 {
+			return string(line), nil
+		} else if err == io.EOF {
+			return "", errors.New("no answer given")
+		} else if err != nil {
+			return "", err
+		}
+	}
+}
@@ -0,0 +1,25 @@
+package clippy
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Authenticator authenticates the user before the action of a command with RequiresAuth, such as by loading a saved token or logging in.
+type Authenticator interface {
+	// Authenticate returns an error if the user is not authenticated and cannot be.
+	// If interactive is true, it may prompt for credentials, since stdin is a terminal that the command does not read its input from.
+	Authenticate(ctx context.Context, interactive bool) error
+}
+
+// authenticate returns a hook that authenticates the user with the Authenticator for the command invoked by path.
+func (c *Clippy) authenticate(path string) Action {
+	return func(flags Flags, args []string) error {
+		interactive := !c.readsStdin && isTerminal(os.Stdin)
+		if err := c.Authenticator.Authenticate(c.ctx, interactive); err != nil {
+			return fmt.Errorf("command %q requires authentication: %v", path, err)
+		}
+		return nil
+	}
+}
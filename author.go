@@ -1,11 +1,29 @@
 package clippy
 
+import "strings"
+
 // Author represents the author of the software.
 type Author struct {
 	Name  string // Name of the author. For example, "Patrick McNamara".
-	Email string // Email of the author. For example, "hello@patrickmcnamara.xyz".
+	Email string // Email of the author. For example, "hello@patrickmcnamara.xyz". It is optional.
+	URL   string // URL of the author's website. It is optional.
+	Role  string // Role of the author. For example, "maintainer". It is optional.
 }
 
+// String returns the author as "Name (Role) <Email> URL", leaving out whichever of them are empty.
 func (a *Author) String() string {
-	return a.Name + " " + "<" + a.Email + ">"
+	var parts []string
+	if a.Name != "" {
+		parts = append(parts, a.Name)
+	}
+	if a.Role != "" {
+		parts = append(parts, "("+a.Role+")")
+	}
+	if a.Email != "" {
+		parts = append(parts, "<"+a.Email+">")
+	}
+	if a.URL != "" {
+		parts = append(parts, a.URL)
+	}
+	return strings.Join(parts, " ")
 }
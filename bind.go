@@ -0,0 +1,126 @@
+package clippy
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	durationType    = reflect.TypeOf(time.Duration(0))
+	stringSliceType = reflect.TypeOf([]string(nil))
+)
+
+// Bind returns flags generated from the fields of the struct that opts points to, and wraps action so the fields are set from the flags before it runs.
+// Each field to bind has a tag like `clippy:"name,alias,description,default"`, where any part can be left empty.
+// The name defaults to the field name in kebab case, such as "dry-run" for DryRun. The description can contain commas if the tag has all four parts.
+// Fields are strings, bools, ints, float64s or time.Durations, which set the flag's Kind, or string slices for Repeatable flags.
+// It panics if opts is not a pointer to a struct or a tagged field is invalid, since that is a mistake in the program.
+func Bind(opts interface{}, action Action) (FlagSet, Action) {
+	v := reflect.ValueOf(opts)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("clippy: Bind needs a pointer to a struct, not %T", opts))
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	var fs FlagSet
+	var fields []int
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, ok := field.Tag.Lookup("clippy")
+		if !ok {
+			continue
+		}
+		flag, err := bindFlag(field, tag)
+		if err != nil {
+			panic("clippy: " + err.Error())
+		}
+		fs = append(fs, flag)
+		fields = append(fields, i)
+	}
+
+	return fs, func(flags Flags, args []string) error {
+		for j, i := range fields {
+			field, name := v.Field(i), fs[j].Name
+			if fs[j].Repeatable {
+				field.Set(reflect.ValueOf(flags.GetStringSlice(name)))
+				continue
+			}
+			switch fs[j].Kind {
+			case StringKind:
+				field.SetString(flags.GetString(name))
+			case BoolKind:
+				field.SetBool(flags.GetBool(name))
+			case IntKind:
+				field.SetInt(int64(flags.GetInt(name)))
+			case FloatKind:
+				field.SetFloat(flags.GetFloat(name))
+			case DurationKind:
+				field.SetInt(int64(flags.GetDuration(name)))
+			}
+		}
+		return action(flags, args)
+	}
+}
+
+// bindFlag returns the flag for a struct field with the given clippy tag.
+func bindFlag(field reflect.StructField, tag string) (*Flag, error) {
+	if field.PkgPath != "" {
+		return nil, fmt.Errorf("cannot bind unexported field %s", field.Name)
+	}
+
+	// Split the tag, leaving commas in the description.
+	parts := strings.SplitN(tag, ",", 3)
+	for len(parts) < 3 {
+		parts = append(parts, "")
+	}
+	flag := &Flag{Name: parts[0], Description: parts[2]}
+	if i := strings.LastIndex(parts[2], ","); i != -1 {
+		flag.Description, flag.DefaultValue = parts[2][:i], parts[2][i+1:]
+	}
+	if flag.Name == "" {
+		flag.Name = kebabCase(field.Name)
+	}
+	if alias := parts[1]; alias != "" {
+		if utf8.RuneCountInString(alias) != 1 {
+			return nil, fmt.Errorf("alias of field %s is not one character: %q", field.Name, alias)
+		}
+		flag.Alias, _ = utf8.DecodeRuneInString(alias)
+	}
+
+	switch kind := field.Type.Kind(); {
+	case field.Type == durationType:
+		flag.Kind = DurationKind
+	case field.Type == stringSliceType:
+		flag.Kind, flag.Repeatable = StringKind, true
+	case kind == reflect.String:
+		flag.Kind = StringKind
+	case kind == reflect.Bool:
+		flag.Kind = BoolKind
+	case kind == reflect.Int || kind == reflect.Int64:
+		flag.Kind = IntKind
+	case kind == reflect.Float64:
+		flag.Kind = FloatKind
+	default:
+		return nil, fmt.Errorf("cannot bind field %s of type %v", field.Name, field.Type)
+	}
+
+	return flag, nil
+}
+
+// kebabCase returns a Go identifier in kebab case, such as "dry-run" for DryRun and "api-key" for APIKey.
+func kebabCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && (unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+			sb.WriteRune('-')
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
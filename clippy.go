@@ -1,161 +1,753 @@
 package clippy
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
 	"strings"
+	"text/template"
+	"time"
 )
 
 // Clippy represents a CLI program.
 type Clippy struct {
-	Name        string     // Name of the program. It is required.
-	Tagline     string     // Tagline of the program.
-	Version     string     // Version of the program. It is required.
-	Description string     // Description of the program.
-	Authors     []Author   // A list of authors of the program.
-	Usage       string     // Usage describes how to use the program. It has a default.
-	Flags       FlagSet    // Global flags used by the program.
-	Commands    CommandSet // Commands are the subcommands of the program.
-	Action      Action     // Action is called when this particular command is.
+	Name           string                         // Name of the program. It is required.
+	Tagline        string                         // Tagline of the program.
+	Version        string                         // Version of the program. It is required.
+	SBOM           bool                           // SBOM enables "--version --sbom", which prints the module path, dependency versions and version control information of the executable as JSON.
+	StrictVersion  bool                           // StrictVersion checks that Version is a valid semantic version.
+	Description    string                         // Description of the program.
+	Authors        []Author                       // A list of authors of the program.
+	Usage          string                         // Usage describes how to use the program. It has a default. The placeholders "{flags}", "{args}" and "{command}" are expanded from the program's flags, Args and Commands.
+	Args           []string                       // Args are the names of the positional arguments of the program. For example, "SOURCE" or "FILES...".
+	StrictArgs     bool                           // StrictArgs rejects more arguments than the program or command declares in Args.
+	Profiling      bool                           // Profiling enables the hidden "--cpuprofile", "--memprofile" and "--pprof-addr" global flags, which profile the action that is run.
+	Tracing        bool                           // Tracing enables the hidden "--trace" global flag, which reports the time spent in each lifecycle phase and each before and after hook.
+	HelpWidthFlag  bool                           // HelpWidthFlag enables the "--help-width" global flag, which wraps help to the given width.
+	NoWarningsFlag bool                           // NoWarningsFlag enables the "--no-warnings" global flag, which suppresses warnings.
+	PrintCmdFlag   bool                           // PrintCmdFlag enables the "--print-command" global flag, which prints the invocation, with the command resolved from any alias, before running it.
+	SingleInstance bool                           // SingleInstance ensures only one instance of the program's action runs at a time. It can be bypassed with the "--no-lock" global flag.
+	LockWait       time.Duration                  // LockWait queues a SingleInstance program for up to this long while another instance runs, instead of failing at once.
+	ConfigFile     string                         // ConfigFile is the path of a JSON file giving flag values, such as "config.json". A relative path is in the user's config directory for the program. It enables the "--config" global flag, which gives another path.
+	ProfileEnv     string                         // ProfileEnv enables profiles in the config file, such as {"profiles": {"staging": {"region": "eu"}}}, whose values take precedence over the rest of the file. The "--profile" global flag selects one, or else the environment variable named by ProfileEnv, such as "APP_PROFILE". It requires ConfigFile.
+	Timeout        time.Duration                  // Timeout enables the "--timeout" global flag, defaulting to this duration, after which the context given to ActionCtx is cancelled. Its deadline tells the action how much time is left.
+	Flags          FlagSet                        // Global flags used by the program. They are inherited by commands, and can be given before or after the command.
+	Commands       CommandSet                     // Commands are the subcommands of the program.
+	Action         Action                         // Action is called when this particular command is.
+	ActionCtx      ActionCtx                      // ActionCtx is called instead of Action if it is set, with the context the program was run with.
+	Authenticator  Authenticator                  // Authenticator authenticates the user for commands with RequiresAuth. It is required if any command has RequiresAuth.
+	Before         Action                         // Before is called before the action of the program or any command, such as to set up logging. If it fails, the action is not run.
+	After          Action                         // After is called after the action of the program or any command, even if it failed, such as to clean up.
+	HelpOnNoArgs   bool                           // HelpOnNoArgs shows help when the program, or a command without an action, is run with no params.
+	NoArgsExitCode int                            // NoArgsExitCode is the exit code used after showing help because of HelpOnNoArgs.
+	Examples       []Example                      // Examples are example invocations of the program, for its help.
+	ExitCodes      []ExitCode                     // ExitCodes are the exit codes the program can exit with, for its help.
+	OptsEnv        string                         // OptsEnv is the name of an environment variable, such as "APP_OPTS", whose contents are split like a shell would and put before the params.
+	PreParse       func(params []string) []string // PreParse rewrites the params before anything else looks at them, such as to expand aliases or translate legacy syntax. It is given the params from OptsEnv too.
+	UsageStats     bool                           // UsageStats counts how often each command and flag is used, in the program's state directory, for the "stats" command made by StatsCommand. The counts never leave the user's machine.
+	Locale         string                         // Locale makes the values of IntKind and FloatKind flags given in the params or environment variables read in the locale's number format, such as "1.234,5" for "de". LocaleEnv takes it from the environment, like LANG. If it is empty, numbers are read like Go does, and defaults and config files always are.
+	Accessibility  bool                           // Accessibility makes output plain text for screen readers, such as help without aligned columns. It can also be turned on with the CLIPPY_A11Y environment variable.
+	Stdout         io.Writer                      // Stdout is where help, version and other output is written. If it is nil, os.Stdout is used.
+	Stderr         io.Writer                      // Stderr is where errors, warnings and prompts are written by default. If it is nil, os.Stderr is used.
+	Suggester      Suggester                      // Suggester suggests commands for mistyped command names. If it is nil, DefaultSuggester is used.
+	HelpTemplate   string                         // HelpTemplate is a text/template that renders the program's help from HelpData, such as to reorder or add sections. If it is empty, the sections are shown in order.
+	HelpConfig     *HelpConfig                    // HelpConfig configures the layout of help output. If it is nil, DefaultHelpConfig is used.
+
+	helpWidth       int             // helpWidth is the line width given by the "--help-width" global flag.
+	noWarnings      bool            // noWarnings is whether the "--no-warnings" global flag was given.
+	noLock          bool            // noLock is whether the "--no-lock" global flag was given.
+	profile         profileFlags    // profile holds the values of the profiling global flags.
+	tracing         bool            // tracing is whether the "--trace" global flag was given.
+	printingCommand bool            // printingCommand is whether the "--print-command" global flag was given.
+	persona         *Command        // persona is the command being run as its own program by Dispatch.
+	parent          *Clippy         // parent is the program this one is mounted in, while it is run as its subcommand.
+	readsStdin      bool            // readsStdin is whether the command being run reads its input from stdin.
+	config          *configSection  // config is the config file, if there is one.
+	configProfile   string          // configProfile is the name of the profile of the config file selected by "--profile" or ProfileEnv, if any.
+	configKeys      []string        // configKeys are the names of the commands being run, which are their sections in the config file.
+	warnings        []string        // warnings are the warnings emitted so far.
+	positions       []int           // positions are where each of the params being run was in the params the program was run with, counting from 1, or 0 for those from OptsEnv.
+	ctx             context.Context // ctx is the context the program was run with.
 }
 
 // Run checks the clippy setup, parses params and runs the parsed command, handling errors it encounters.
 func (c *Clippy) Run(params []string) {
-	// Establish error handlers.
-	var (
-		// Only use error handler if err != nil.
-		chkErr = func(err error, errHandler ErrHandler) {
-			if err != nil {
-				errHandler(c.Name, err)
-			}
+	c.handleErr(c.RunE(params))
+}
+
+// RunContext is like Run, but cancels ctx when the program receives an interrupt or termination signal.
+// The context is given to ActionCtx, so long-running actions can shut down gracefully.
+func (c *Clippy) RunContext(ctx context.Context, params []string) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, shutdownSignals...)
+	defer signal.Stop(signals)
+	go func() {
+		select {
+		case <-signals:
+			cancel()
+		case <-ctx.Done():
 		}
+	}()
 
-		// Action, parse and setup error handler using chkErr.
-		actionErr = func(err error) { chkErr(err, ActionErrHandler) }
-		parseErr  = func(err error) { chkErr(err, ParseErrHandler) }
-		setupErr  = func(err error) { chkErr(err, SetupErrHandler) }
-	)
+	c.handleErr(c.runE(ctx, params))
+}
+
+// RunE is like Run, but returns the error it encounters instead of handling it, so the caller can decide how to terminate.
+// The error is a *SetupError, *ParseError or *ActionError, depending on where it happened.
+// It can be called again with other params, since nothing is carried over from one run to the next.
+func (c *Clippy) RunE(params []string) error {
+	return c.runE(context.Background(), params)
+}
+
+func (c *Clippy) runE(ctx context.Context, params []string) error {
+	// Forget the state of the last run, so the program can be run again with other params, such as by a REPL or tests.
+	c.ctx = ctx
+	c.helpWidth, c.noWarnings, c.noLock, c.printingCommand = 0, false, false, false
+	c.profile, c.tracing = profileFlags{}, false
+	c.readsStdin = false
+	c.config, c.configKeys, c.configProfile = nil, nil, ""
+	c.warnings = nil
+
+	// Remember the position of each param, so errors can point at it however the params are rearranged.
+	// The name of a persona's command is not one of the params it was run with.
+	c.positions = make([]int, len(params))
+	for i := range params {
+		c.positions[i] = i + 1
+		if c.persona != nil {
+			c.positions[i] = i
+		}
+	}
+
+	// Put the params from the environment first.
+	if opts := os.Getenv(c.OptsEnv); c.OptsEnv != "" && opts != "" {
+		envParams, err := splitShell(opts)
+		if err != nil {
+			return &ParseError{Err: fmt.Errorf("cannot split $%s: %v", c.OptsEnv, err)}
+		}
+		params = append(envParams, params...)
+		c.positions = append(make([]int, len(envParams)), c.positions...)
+	}
+
+	// Rewrite the params if asked.
+	if c.PreParse != nil {
+		rewritten := c.PreParse(append([]string{}, params...))
+		c.positions = alignPositions(params, c.positions, rewritten)
+		params = rewritten
+	}
+
+	// Check whether to trace lifecycle phases.
+	if c.Tracing {
+		params, c.tracing = c.removeParam(params, "--trace")
+	}
 
 	// Check for errors with commands and flags.
-	setupErr(c.Check())
+	start := time.Now()
+	err := c.Check()
+	c.trace("check", start)
+	if err != nil {
+		return &SetupError{err}
+	}
+
+	// Take the help width from the params if it is there.
+	if c.HelpWidthFlag {
+		if params, c.helpWidth, err = c.parseHelpWidth(params); err != nil {
+			return &ParseError{Err: err}
+		}
+	}
+
+	// Check whether to suppress warnings.
+	if c.NoWarningsFlag {
+		params, c.noWarnings = c.removeParam(params, "--no-warnings")
+	}
+
+	// Check whether to print the invocation.
+	if c.PrintCmdFlag {
+		params, c.printingCommand = c.removeParam(params, "--print-command")
+	}
+
+	// Check whether to skip single instance locks.
+	if c.hasSingleInstance() {
+		params, c.noLock = c.removeParam(params, "--no-lock")
+	}
+
+	// Keep the global flags given to the program this one is mounted in, which took them from the params.
+	if p := c.parent; p != nil {
+		if c.helpWidth == 0 {
+			c.helpWidth = p.helpWidth
+		}
+		c.noWarnings = c.noWarnings || p.noWarnings
+		c.printingCommand = c.printingCommand || p.printingCommand
+		c.noLock = c.noLock || p.noLock
+	}
+
+	// Take the profiling flags from the params if they are enabled.
+	if c.Profiling {
+		if params, c.profile, err = c.removeProfileFlags(params); err != nil {
+			return &ParseError{Err: err}
+		}
+	}
+
+	// Load the config file for flag values that are not given otherwise.
+	if c.ConfigFile != "" {
+		if params, c.config, err = c.loadConfig(params); err != nil {
+			return &ParseError{Err: err}
+		}
+	}
+
+	// Select the profile of the config file if profiles are enabled.
+	if c.ProfileEnv != "" {
+		if params, c.configProfile, err = c.selectProfile(params); err != nil {
+			return &ParseError{Err: err}
+		}
+	}
+
+	// Set the deadline of the action if there is a timeout.
+	if c.Timeout > 0 {
+		var d time.Duration
+		if params, d, err = c.timeout(params, c.Timeout); err != nil {
+			return &ParseError{Err: err}
+		}
+		if d > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, d)
+			defer cancel()
+			c.ctx = ctx
+		}
+	}
+
+	// Show help if there are no params and that is the policy.
+	if len(params) == 0 && c.HelpOnNoArgs {
+		return c.noArgsHelp(c.stdout(), c.help(false))
+	}
+
+	// Move the command before any global flags given ahead of it.
+	if n := c.Flags.skip(params); n >= 1 && n < len(params) && c.Commands.get(params[n]) != nil {
+		params = c.moveCommand(params, 0, n)
+	}
 
 	// Run subcommand or help or version if it's there.
 	if len(params) >= 1 {
 		p1 := params[0]
 		if command := c.Commands.get(p1); command != nil {
-			parseErr(command.run(c.Name, params[1:]))
-			return
+			return command.run(c, c.commandPath(command), params[1:], 1)
 		} else if p1 == "-h" || p1 == "--help" {
-			fmt.Println(c.help())
-			return
+			return c.println(c.help(false))
+		} else if p1 == "--help-all" {
+			return c.println(c.help(true))
+		} else if p1 == "--list-commands" {
+			list, err := c.listCommands(params[1:])
+			if err != nil {
+				return &ParseError{Err: err}
+			}
+			return c.println(list)
+		} else if p1 == "--tree" {
+			return c.println(c.tree())
+		} else if p1 == "help" && len(c.Commands) >= 1 {
+			return c.helpCommand(params[1:])
+		} else if (p1 == "-v" || p1 == "--version") && c.SBOM && len(params) >= 2 && params[1] == "--sbom" {
+			sbom, err := c.sbom()
+			if err != nil {
+				return &ActionError{err}
+			}
+			return c.println(sbom)
 		} else if p1 == "-v" || p1 == "--version" {
-			fmt.Println(c.version())
-			return
+			return c.println(c.version())
+		}
+	}
+
+	// Check for a mistyped command.
+	if len(params) >= 1 && len(c.Commands) >= 1 && !strings.HasPrefix(params[0], "-") {
+		if err := c.Commands.unknown(params[0], c.Action == nil && c.ActionCtx == nil, c.Suggester); err != nil {
+			return &ParseError{Err: err}
 		}
 	}
 
 	// Parse flags and arguments.
-	flags, args, err := c.Flags.parse(params)
-	parseErr(err)
+	start = time.Now()
+	flags, args, err := c.Flags.parse(c, params, 0)
+	c.trace("parse", start)
+	if err != nil {
+		return parseError(err)
+	}
+
+	// Check for unexpected arguments.
+	if c.StrictArgs {
+		if err := checkArgs(c.Args, args); err != nil {
+			return &ParseError{Err: err}
+		}
+	}
 
 	// Run default action if none is set.
-	if c.Action == nil {
-		parseErr(HelpAction(flags, args))
+	if c.Action == nil && c.ActionCtx == nil {
+		if err := HelpAction(flags, args); err != nil {
+			return &ParseError{Err: err}
+		}
+		return nil
 	}
+
+	// Take the lock if only one instance may run.
+	if c.SingleInstance {
+		unlock, err := c.lock(c.Name, c.LockWait)
+		if err != nil {
+			return &ActionError{err}
+		}
+		defer unlock()
+	}
+
+	// Print the invocation if asked, and count it if asked.
+	c.printCommand(c.Name, c.Flags, flags, args)
+	c.recordUsage(c.Name, flags)
+
 	// Otherwise run given action.
-	actionErr(c.Action(flags, args))
+	return c.runAction([]hook{{"before " + c.Name, c.Before}}, action(ctx, c.Action, c.ActionCtx), []hook{{"after " + c.Name, c.After}}, flags, args)
+}
+
+// noArgsHelp prints help shown because of HelpOnNoArgs to w, returning an error to exit with NoArgsExitCode if it is not zero.
+func (c *Clippy) noArgsHelp(w io.Writer, help string) error {
+	if err := c.fprintln(w, help); err != nil {
+		return err
+	}
+	if c.NoArgsExitCode != 0 {
+		return &ExitError{Code: c.NoArgsExitCode}
+	}
+	return nil
+}
+
+// runAction runs action between the before and after hooks, wrapping it with the profiling asked for by the global flags.
+// The action is not run if a before hook fails, but the after hooks always are once it has run. Any error is returned as an *ActionError.
+func (c *Clippy) runAction(before []hook, action Action, after []hook, flags Flags, args []string) error {
+	stop, err := c.profile.start()
+	if err != nil {
+		return &ActionError{err}
+	}
+	start := time.Now()
+	if err = c.runHooks(before, true, flags, args); err == nil {
+		start = time.Now()
+		err = action(flags, args)
+		c.trace("action", start)
+		if herr := c.runHooks(after, false, flags, args); err == nil {
+			err = herr
+		}
+	}
+	if serr := stop(); err == nil {
+		err = serr
+	}
+	if err != nil {
+		return &ActionError{err}
+	}
+	return nil
+}
+
+// hook is a before or after hook of an action, with the label its time is traced under, such as "before app build".
+type hook struct {
+	label  string
+	action Action
+}
+
+// runHooks runs the hooks that are set, tracing each, and returns the first error. If stopOnErr is true, the hooks after a failing one are not run.
+func (c *Clippy) runHooks(hooks []hook, stopOnErr bool, flags Flags, args []string) error {
+	var err error
+	for _, hook := range hooks {
+		if hook.action == nil {
+			continue
+		}
+		start := time.Now()
+		herr := hook.action(flags, args)
+		c.trace(hook.label, start)
+		if err == nil {
+			err = herr
+		}
+		if err != nil && stopOnErr {
+			break
+		}
+	}
+	return err
 }
 
 // Check checks clippy.
 func (c *Clippy) Check() error {
+	// Check that the version is a semantic version if asked.
+	if c.StrictVersion {
+		if err := ValidateVersion(c.Version); err != nil {
+			return err
+		}
+	}
+	// Check that the program has at most one action.
+	if c.Action != nil && c.ActionCtx != nil {
+		return fmt.Errorf("program %q has both Action and ActionCtx", c.Name)
+	}
+
+	// Check that profiles are of a config file.
+	if c.ProfileEnv != "" && c.ConfigFile == "" {
+		return fmt.Errorf("program %q has a ProfileEnv but no ConfigFile", c.Name)
+	}
+
+	// Check that the locale is known.
+	if err := checkLocale(c.Locale); err != nil {
+		return err
+	}
+
 	// Check for errors with flags.
 	if err := c.Flags.check(); err != nil {
 		return err
 	}
 
+	// Check that each exit code is unique.
+	if err := checkExitCodes(c.ExitCodes); err != nil {
+		return err
+	}
+
 	// Check for errors with commands.
 	if err := c.Commands.check(); err != nil {
 		return err
 	}
 
-	return nil
+	// Check that no flag uses the name of a global flag, which is taken from the params before flags are parsed.
+	var err error
+	reserved := c.reservedFlags()
+	checkReserved := func(path string, fs FlagSet) {
+		for _, f := range fs {
+			for _, name := range append([]string{f.Name}, f.LongAliases...) {
+				if _, ok := reserved[name]; ok && err == nil {
+					err = fmt.Errorf("flag %q of %q uses the name of global flag %q", f.Name, path, "--"+name)
+				}
+			}
+		}
+	}
+	var checkCommands func(path string, cs CommandSet)
+	checkCommands = func(path string, cs CommandSet) {
+		cs.walk(path, func(path string, command *Command) {
+			if command.mounted != nil {
+				checkReserved(path, command.mounted.Flags)
+				checkCommands(path, command.mounted.Commands)
+			}
+			checkReserved(path, command.flags())
+		})
+	}
+	checkReserved(c.Name, c.Flags)
+	checkCommands(c.Name, c.Commands)
+	if err != nil {
+		return err
+	}
+
+	// Check for deprecated commands and flags that should have been removed.
+	check := func(fs FlagSet) error {
+		for _, f := range fs {
+			if err := c.checkDeprecated(fmt.Sprintf("flag %q", f.Name), f.RemoveInVersion); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := check(c.Flags); err != nil {
+		return err
+	}
+	c.Commands.walk(c.Name, func(path string, command *Command) {
+		if err == nil {
+			err = c.checkDeprecated(fmt.Sprintf("command %q", path), command.RemoveInVersion)
+		}
+		if err == nil {
+			err = check(command.flags())
+		}
+		if err == nil {
+			fs := command.flags()
+			fs = fs.inherit(c.inheritedFlags(command))
+			_, err = orderTemplated(fs.templated())
+		}
+		inheritable := c.inheritableFlags(command)
+		for _, name := range command.SuppressFlags {
+			if err == nil && inheritable.get("--"+name) == nil {
+				err = fmt.Errorf("command %q suppresses flag %q, which it does not inherit", path, name)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// Check that each command supports the program's version.
+	c.Commands.walk(c.Name, func(path string, command *Command) {
+		if err == nil {
+			err = c.checkSupported(fmt.Sprintf("command %q", path), command.MinAppVersion, command.MaxAppVersion)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// Check that the help templates parse.
+	if _, err := template.New("help").Parse(c.HelpTemplate); err != nil {
+		return fmt.Errorf("invalid help template: %v", err)
+	}
+	c.Commands.walk(c.Name, func(path string, command *Command) {
+		if _, terr := template.New("help").Parse(command.HelpTemplate); err == nil && terr != nil {
+			err = fmt.Errorf("invalid help template of command %q: %v", path, terr)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	// Check that there is an Authenticator for commands that require authentication.
+	if c.Authenticator == nil {
+		c.Commands.walk(c.Name, func(path string, command *Command) {
+			if err == nil && command.RequiresAuth {
+				err = fmt.Errorf("command %q requires authentication but the program has no Authenticator", path)
+			}
+		})
+	}
+	return err
+}
+
+// reservedFlags returns the names of the enabled global flags that are taken from the params before flags are parsed, so no flag can use them.
+// Each maps to whether the global flag takes a value.
+func (c *Clippy) reservedFlags() map[string]bool {
+	reserved := make(map[string]bool)
+	if c.HelpWidthFlag {
+		reserved["help-width"] = true
+	}
+	if c.NoWarningsFlag {
+		reserved["no-warnings"] = false
+	}
+	if c.PrintCmdFlag {
+		reserved["print-command"] = false
+	}
+	if c.hasSingleInstance() {
+		reserved["no-lock"] = false
+	}
+	if c.Tracing {
+		reserved["trace"] = false
+	}
+	if c.Profiling {
+		reserved["cpuprofile"], reserved["memprofile"], reserved["pprof-addr"] = true, true, true
+	}
+	if c.ConfigFile != "" {
+		reserved["config"] = true
+	}
+	if c.ProfileEnv != "" {
+		reserved["profile"] = true
+	}
+	if c.Timeout > 0 {
+		reserved["timeout"] = true
+	}
+	return reserved
+}
+
+// inheritedFlags returns the flags that command inherits: the persistent flags of the commands it is nested in, nearest first, then the program's flags.
+// The flags suppressed by the command, or by a command it is nested in, are left out.
+func (c *Clippy) inheritedFlags(command *Command) FlagSet {
+	flags := c.inheritableFlags(command)
+	return flags.without(command.SuppressFlags)
+}
+
+// inheritableFlags returns the flags that command inherits before leaving out those it suppresses itself.
+func (c *Clippy) inheritableFlags(command *Command) FlagSet {
+	flags := c.Flags
+	for _, ancestor := range c.Commands.ancestors(command) {
+		flags = append(append(FlagSet{}, ancestor.PersistentFlags...), flags.without(ancestor.SuppressFlags)...)
+	}
+	return flags
+}
+
+// commandPath returns how a top-level command is invoked, such as "app build", or just "build" when it is being run as a persona.
+func (c *Clippy) commandPath(command *Command) string {
+	if c.persona == command {
+		return command.Names[0]
+	}
+	return c.Name + " " + command.Names[0]
+}
+
+func (c *Clippy) hasSingleInstance() bool {
+	has := c.SingleInstance
+	c.Commands.walk(c.Name, func(path string, command *Command) {
+		has = has || command.SingleInstance
+	})
+	return has
+}
+
+func (c *Clippy) hasAdvanced() bool {
+	has := c.Flags.hasAdvanced()
+	c.Commands.walkVisible(c.Name, func(path string, command *Command) {
+		flags := command.flags()
+		has = has || flags.hasAdvanced()
+	})
+	return has
+}
+
+// hasDocs returns whether any visible flag or command has a DocsURL, which "--help-all" shows.
+func (c *Clippy) hasDocs() bool {
+	flags := c.Flags.visible()
+	has := flags.docs("") != ""
+	c.Commands.walkVisible(c.Name, func(path string, command *Command) {
+		flags := command.flags().visible()
+		has = has || command.DocsURL != "" || flags.docs("") != ""
+	})
+	return has
+}
+
+func (c *Clippy) helpConfig() *HelpConfig {
+	hc := DefaultHelpConfig
+	if c.HelpConfig != nil {
+		hc = *c.HelpConfig
+	}
+	if c.helpWidth > 0 {
+		hc.LineWidth = c.helpWidth
+	} else if hc.LineWidth == 0 {
+		hc.LineWidth = envWidth()
+	}
+	if f, ok := c.stdout().(*os.File); ok && hc.LineWidth == 0 {
+		hc.LineWidth = terminalWidth(f)
+	}
+	hc.Accessible = hc.Accessible || c.Accessible()
+	return &hc
+}
+
+// Accessible returns whether output should be accessible, because of Accessibility or the CLIPPY_A11Y environment variable.
+// Actions should check it to avoid spinners, progress animations and signalling with colour alone.
+func (c *Clippy) Accessible() bool {
+	return c.Accessibility || envAccessible()
 }
 
 func (c *Clippy) version() string {
 	return c.Name + " " + c.Version
 }
 
-func (c *Clippy) help() string {
-	var sb strings.Builder
+// helpCommand runs the built-in "help" command with the given params, which can be a command path such as "remote add", "--all" or "--search QUERY".
+// It is only built in for programs with commands, and a command named "help" takes its place.
+func (c *Clippy) helpCommand(params []string) error {
+	var names []string
+	all := false
+	for i := 0; i < len(params); i++ {
+		switch param := params[i]; {
+		case param == "--search" && i+1 < len(params):
+			return c.println(c.search(params[i+1]))
+		case param == "--search":
+			return &ParseError{Err: fmt.Errorf("no corresponding value for flag: %q", param)}
+		case param == "--all":
+			all = true
+		case strings.HasPrefix(param, "-"):
+			return &ParseError{Err: fmt.Errorf("unknown flag for help: %q", param)}
+		default:
+			names = append(names, param)
+		}
+	}
+	help, err := c.commandHelp(names, all)
+	if err != nil {
+		return &ParseError{Err: err}
+	}
+	return c.println(help)
+}
+
+// usage returns how to use the program, from Usage or its default.
+func (c *Clippy) usage() string {
+	if c.Usage != "" {
+		return expandUsage(c.Usage, c.Flags, c.Args, c.Commands)
+	}
+	return "[global flags...] [command] [flags and values...] " + argsUsage(c.Args)
+}
+
+func (c *Clippy) help(all bool) string {
+	var sections helpSections
+	hc := c.helpConfig()
 
 	// NAME and TAGLINE
-	sb.WriteString("NAME:\n")
-	sb.WriteString("\t" + c.Name)
+	name := hc.Indent + c.Name
 	if c.Tagline != "" {
-		sb.WriteString(" - " + c.Tagline)
+		name += " - " + c.Tagline
 	}
-	sb.WriteString("\n\n")
+	sections.add("NAME", name+"\n")
 
 	// VERSION
-	sb.WriteString("VERSION:\n")
-	sb.WriteString("\t" + c.Version + "\n\n")
+	sections.add("VERSION", hc.Indent+c.Version+"\n")
 
 	// DESCRIPTION
 	if c.Description != "" {
-		sb.WriteString("DESCRIPTION:\n")
-		sb.WriteString("\t" + c.Description + "\n\n")
+		sections.add("DESCRIPTION", hc.paragraph(c.Description))
 	}
 
 	// AUTHOR(S)
 	if len(c.Authors) >= 1 {
-		sb.WriteString("AUTHOR")
-		if len(c.Authors) > 1 {
-			sb.WriteString("S:\n")
-		} else {
-			sb.WriteString(":\n")
-		}
+		var sb strings.Builder
 		for _, author := range c.Authors {
-			sb.WriteString("\t" + author.String() + "\n")
+			sb.WriteString(hc.Indent + author.String() + "\n")
 		}
-		sb.WriteRune('\n')
+		sections.add(plural("AUTHOR", len(c.Authors)), sb.String())
 	}
 
 	// USAGE
-	sb.WriteString("USAGE:\n")
-	usage := "[global flags...] [command] [flags and values...] [arguments...]"
-	if c.Usage != "" {
-		usage = c.Usage
-	}
-	sb.WriteString("\t" + c.Name + " " + usage + "\n\n")
+	sections.add("USAGE", hc.Indent+c.Name+" "+c.usage()+"\n")
 
 	// GLOBAL FLAGS
-	sb.WriteString("GLOBAL FLAGS:\n")
-	sb.WriteString("\t" + "--help, -h  \tshow help (with optional subcommand) and exit\n")
-	sb.WriteString("\t" + "--version, -v  \tshow version and exit\n")
-	sb.WriteRune('\n')
+	globalFlags := []helpEntry{
+		{name: "--help", aliases: []string{"-h"}, description: "show help (with optional subcommand) and exit"},
+		{name: "--version", aliases: []string{"-v"}, description: "show version and exit"},
+		{name: "--list-commands", description: "list all commands (as JSON with --json) and exit"},
+		{name: "--tree", description: "show the command tree and exit"},
+	}
+	if c.HelpWidthFlag {
+		globalFlags = append(globalFlags, helpEntry{name: "--help-width", description: "wrap help to the given width"})
+	}
+	if c.NoWarningsFlag {
+		globalFlags = append(globalFlags, helpEntry{name: "--no-warnings", description: "suppress warnings"})
+	}
+	if c.PrintCmdFlag {
+		globalFlags = append(globalFlags, helpEntry{name: "--print-command", description: "print the invocation before running it"})
+	}
+	if c.SBOM {
+		globalFlags[1].description = "show version (with modules and build details as JSON with --sbom) and exit"
+	}
+	if c.hasSingleInstance() {
+		globalFlags = append(globalFlags, helpEntry{name: "--no-lock", description: "run even if another instance is running"})
+	}
+	if c.ConfigFile != "" {
+		globalFlags = append(globalFlags, helpEntry{name: "--config", description: "read flag values from the given JSON file (default: " + c.ConfigFile + ")"})
+	}
+	if c.ProfileEnv != "" {
+		globalFlags = append(globalFlags, helpEntry{name: "--profile", description: "read flag values from the given profile of the config file first (env: $" + c.ProfileEnv + ")"})
+	}
+	if c.Timeout > 0 {
+		globalFlags = append(globalFlags, helpEntry{name: "--timeout", description: fmt.Sprintf("cancel the action after the given duration, or never if it is 0 (default: %v)", c.Timeout)})
+	}
+	if advanced, docs := c.hasAdvanced(), c.hasDocs(); advanced && docs {
+		globalFlags = append(globalFlags, helpEntry{name: "--help-all", description: "show help including advanced flags and documentation links and exit"})
+	} else if advanced {
+		globalFlags = append(globalFlags, helpEntry{name: "--help-all", description: "show help including advanced flags and exit"})
+	} else if docs {
+		globalFlags = append(globalFlags, helpEntry{name: "--help-all", description: "show help including documentation links and exit"})
+	}
+	sections.add("GLOBAL FLAGS", hc.table(globalFlags))
 
-	// COMMANDS
-	if len(c.Commands) >= 1 {
-		sb.WriteString("COMMAND")
-		if len(c.Commands) > 1 {
-			sb.WriteString("S:\n")
-		} else {
-			sb.WriteString(":\n")
-		}
-		sb.WriteString(c.Commands.help("\t"))
-		sb.WriteRune('\n')
+	// COMMAND(S)
+	commands := c.Commands.visible()
+	if len(commands) >= 1 {
+		sections.add(plural("COMMAND", len(commands)), commands.help(hc))
 	}
 
-	// FLAGS
-	if len(c.Flags) >= 1 {
-		sb.WriteString("FLAG")
-		if len(c.Flags) > 1 {
-			sb.WriteString("S:\n")
-		} else {
-			sb.WriteString(":\n")
-		}
-		sb.WriteString(c.Flags.help("\t"))
-		sb.WriteRune('\n')
+	// FLAG(S)
+	flags := c.Flags.visible()
+	if len(flags) >= 1 {
+		sections.add(plural("FLAG", len(flags)), flags.help(hc, all))
+	}
+
+	// EXAMPLE(S)
+	if len(c.Examples) >= 1 {
+		sections.add(plural("EXAMPLE", len(c.Examples)), examplesHelp(hc, c.Examples))
+	}
+
+	// EXIT CODES
+	if len(c.ExitCodes) >= 1 {
+		sections.add("EXIT CODES", exitCodesHelp(hc, c.ExitCodes))
+	}
+
+	// DOCUMENTATION
+	if docs := commands.docs(hc.Indent) + flags.docs(hc.Indent); all && docs != "" {
+		sections.add("DOCUMENTATION", docs)
 	}
 
-	return strings.TrimRight(sb.String(), "\n")
+	return c.renderHelp(c.HelpTemplate, &HelpData{Name: c.Name, Sections: sections, Program: c})
 }
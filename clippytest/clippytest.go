@@ -0,0 +1,45 @@
+// Package clippytest provides helpers for testing programs built with clippy.
+package clippytest
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+)
+
+// ModeEnv is the environment variable that makes the test binary run as the program instead of running its tests.
+const ModeEnv = "CLIPPY_TEST_MODE"
+
+// Main runs c with the command line params and exits if the test binary was started by Command. Otherwise, it runs the tests with m and exits.
+// It is to be called from TestMain, so integration tests can exercise real exit statuses and signal handling.
+func Main(m *testing.M, c *clippy.Clippy) {
+	if os.Getenv(ModeEnv) == "1" {
+		c.RunContext(context.Background(), os.Args[1:])
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// Command returns a command that runs the test binary as the program with params, for a test to start and check the output and exit status of.
+// The test binary must call Main from TestMain. The program inherits the test's environment, such as GOCOVERDIR for coverage, with env added.
+func Command(params []string, env ...string) *exec.Cmd {
+	cmd := exec.Command(os.Args[0], params...)
+	cmd.Env = append(append(os.Environ(), ModeEnv+"=1"), env...)
+	return cmd
+}
+
+// Execute runs c in-process with params, without exiting, and returns what it wrote to stdout and stderr.
+// code is the exit status the program would exit with and err is the error returned by RunE, if any, so help text and command behavior can be checked or compared against golden files.
+func Execute(c *clippy.Clippy, params ...string) (stdout, stderr string, code int, err error) {
+	var outBuf, errBuf bytes.Buffer
+	oldStdout, oldStderr := c.Stdout, c.Stderr
+	c.Stdout, c.Stderr = &outBuf, &errBuf
+	defer func() { c.Stdout, c.Stderr = oldStdout, oldStderr }()
+
+	err = c.RunE(params)
+	return outBuf.String(), errBuf.String(), clippy.ExitStatus(err), err
+}
@@ -3,16 +3,42 @@ package clippy
 import (
 	"fmt"
 	"strings"
+	"time"
 	"unicode"
 )
 
 // Command is a subcommand for a program.
 type Command struct {
-	Names       []string // Name and aliases of the command. It is required.
-	Description string   // Description of the command.
-	Usage       string   // Usage describes how to use the command. It has a default.
-	Flags       FlagSet  // Flags used by the program.
-	Action      Action   // Action is called when this particular command is.
+	Names           []string      // Name and aliases of the command. It is required.
+	HiddenNames     []string      // HiddenNames are aliases in Names that still work but are left out of help, such as legacy aliases.
+	Hidden          bool          // Hidden commands can be run but are left out of help, documentation and completion, such as internal or debugging commands.
+	Description     string        // Description of the command.
+	Usage           string        // Usage describes how to use the command. It has a default. The placeholders "{flags}", "{args}" and "{command}" are expanded from the command's flags, Args and Commands.
+	Flags           FlagSet       // Flags used by the program.
+	PersistentFlags FlagSet       // PersistentFlags are flags of the command that its nested subcommands inherit too, such as "--remote" for every "app remote" command.
+	SuppressFlags   []string      // SuppressFlags are the names of inherited flags that make no sense for the command and its nested subcommands, which are then left out of their help and completion and cannot be given to them. To override an inherited flag instead, give the command a flag with the same name.
+	Commands        CommandSet    // Commands are the nested subcommands of the command, such as "add" in "app remote add".
+	FlagGroups      []*FlagGroup  // FlagGroups are shared groups of flags used by the command, in addition to Flags.
+	Args            []string      // Args are the names of the positional arguments of the command. For example, "SOURCE" or "FILES...".
+	HelpTemplate    string        // HelpTemplate is a text/template that renders the command's help from HelpData. If it is empty, the sections are shown in order.
+	Examples        []Example     // Examples are example invocations of the command, for its help.
+	ExitCodes       []ExitCode    // ExitCodes are the exit codes the command can exit with, for its help.
+	DocsURL         string        // DocsURL links to further documentation of the command, in man pages, markdown and the help shown by "--help-all".
+	Deprecated      string        // Deprecated marks the command as deprecated with a message, for example saying what to use instead. A warning is given when it is used.
+	RemoveInVersion string        // RemoveInVersion is the version the deprecated command will be removed in. Once the program reaches this version, it fails its check.
+	MinAppVersion   string        // MinAppVersion is the earliest version of the program the command supports, such as for a mounted component. An earlier program fails its check.
+	MaxAppVersion   string        // MaxAppVersion is the latest version of the program the command supports. A later program fails its check.
+	ReadsStdin      bool          // ReadsStdin declares that the command reads its input from stdin, so flags never prompt for their values on it. It is noted in help.
+	BinaryStdout    bool          // BinaryStdout declares that the command writes binary data to stdout, so nothing else is ever written there: its help goes to stderr, like prompts, warnings and the "--print-command" echo. It is noted in help.
+	SingleInstance  bool          // SingleInstance ensures only one instance of the command runs at a time. It can be bypassed with the "--no-lock" global flag.
+	LockWait        time.Duration // LockWait queues a SingleInstance command for up to this long while another instance runs, instead of failing at once.
+	Action          Action        // Action is called when this particular command is.
+	ActionCtx       ActionCtx     // ActionCtx is called instead of Action if it is set, with the context the program was run with.
+	RequiresAuth    bool          // RequiresAuth authenticates the user with the program's Authenticator before the command's Before and action. If it fails, they are not run.
+	Before          Action        // Before is called before the command's action, after the program's Before. If it fails, the action is not run.
+	After           Action        // After is called after the command's action, even if it failed, before the program's After.
+
+	mounted *Clippy // mounted is the program run by this command, if it was made by Mount.
 }
 
 func (c *Command) check() error {
@@ -30,73 +56,275 @@ func (c *Command) check() error {
 		}
 	}
 
-	// Check each flag in command's flagset.
-	for _, f := range c.Flags {
-		if err := f.check(); err != nil {
-			return err
+	// Check that each hidden name is an alias of the command.
+	for _, hidden := range c.HiddenNames {
+		found := false
+		for _, name := range c.Names[1:] {
+			if name == hidden {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("hidden name %q is not an alias of command %q", hidden, c.Names[0])
 		}
 	}
 
+	// Check that the command has at most one action.
+	if c.Action != nil && c.ActionCtx != nil {
+		return fmt.Errorf("command %q has both Action and ActionCtx", c.Names[0])
+	}
+
+	// Check that each exit code is unique.
+	if err := checkExitCodes(c.ExitCodes); err != nil {
+		return err
+	}
+
+	// Check that each flag group has a name.
+	for _, group := range c.FlagGroups {
+		if group.Name == "" {
+			return fmt.Errorf("missing name of flag group in command %q", c.Names[0])
+		}
+	}
+
+	// Check the command's flagset, including its flag groups and persistent flags.
+	flags := c.flags()
+	if err := flags.check(); err != nil {
+		return err
+	}
+
+	// Check the command's subcommands.
+	if err := c.Commands.check(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
-func (c *Command) run(name string, params []string) error {
-	// Check for help flag.
-	if len(params) >= 1 && params[0] == "-h" || params[0] == "--help" {
-		fmt.Println(c.help(name))
-		return nil
+// run runs the command with the given params. The path is how the command was invoked, such as "app remote add".
+// The params are the program's from offset on.
+func (c *Command) run(app *Clippy, path string, params []string, offset int) error {
+	// Run the mounted program if there is one.
+	if c.mounted != nil {
+		return c.runMounted(app, path, params, offset)
+	}
+	app.configKeys = append(app.configKeys, c.Names[0])
+
+	fs := c.flags()
+	fs = fs.inherit(app.inheritedFlags(c))
+
+	// Move the nested subcommand before any inherited or persistent flags given ahead of it.
+	if n := fs.skip(params); n >= 1 && n < len(params) && c.Commands.get(params[n]) != nil {
+		params = app.moveCommand(params, offset, n)
+	}
+
+	// Run nested subcommand if it's there.
+	if len(params) >= 1 {
+		if command := c.Commands.get(params[0]); command != nil {
+			return command.run(app, path+" "+command.Names[0], params[1:], offset+1)
+		}
+	}
+
+	// Check for a mistyped nested subcommand.
+	if len(params) >= 1 && len(c.Commands) >= 1 && !strings.HasPrefix(params[0], "-") {
+		if err := c.Commands.unknown(params[0], c.Action == nil && c.ActionCtx == nil, app.Suggester); err != nil {
+			return &ParseError{Err: err}
+		}
+	}
+
+	// Keep stdout for the binary output of a command that declares it, so its help goes to stderr.
+	out := app.stdout()
+	if c.BinaryStdout {
+		out = app.stderr()
 	}
 
+	// Show help if there are no params and that is the policy.
+	if len(params) == 0 && c.Action == nil && c.ActionCtx == nil && app.HelpOnNoArgs {
+		return app.noArgsHelp(out, c.help(app, path, false))
+	}
+
+	// Check for help flags.
+	if len(params) >= 1 {
+		if p1 := params[0]; p1 == "-h" || p1 == "--help" {
+			return app.fprintln(out, c.help(app, path, false))
+		} else if p1 == "--help-all" {
+			return app.fprintln(out, c.help(app, path, true))
+		} else if (p1 == "-v" || p1 == "--version") && app.persona == c && fs.get(p1) == nil {
+			return app.fprintln(out, path+" "+app.Version)
+		}
+	}
+
+	// Warn if the command is deprecated.
+	if c.isDeprecated() {
+		app.warnDeprecated(fmt.Sprintf("command %q", c.Names[0]), c.Deprecated, c.RemoveInVersion)
+	}
+
+	// Keep stdin for the command's input if it is declared.
+	app.readsStdin = c.ReadsStdin
+
 	// Parse parameters for flags and arguments.
-	flags, args, err := c.Flags.parse(params)
+	start := time.Now()
+	flags, args, err := fs.parse(app, params, offset)
+	app.trace("parse", start)
 	if err != nil {
-		return err
+		return parseError(err)
+	}
+
+	// Check for unexpected arguments.
+	if app.StrictArgs {
+		if err := checkArgs(c.Args, args); err != nil {
+			return &ParseError{Err: err}
+		}
+	}
+
+	// Take the lock if only one instance may run.
+	if c.SingleInstance {
+		unlock, err := app.lock(strings.Replace(path, " ", "-", -1), c.LockWait)
+		if err != nil {
+			return &ActionError{err}
+		}
+		defer unlock()
 	}
 
+	// Print the resolved invocation if asked, and count it if asked.
+	app.printCommand(path, fs, flags, args)
+	app.recordUsage(path, flags)
+
+	// Authenticate after the program's Before, so it can set up what authenticating needs.
+	before := []hook{{"before " + app.Name, app.Before}, {"before " + path, c.Before}}
+	if c.RequiresAuth {
+		before = []hook{before[0], {"authenticate " + path, app.authenticate(path)}, before[1]}
+	}
+	after := []hook{{"after " + path, c.After}, {"after " + app.Name, app.After}}
+
 	// Check if there is a default action.
-	if c.Action == nil {
-		return DefaultAction(flags, args)
+	if c.Action == nil && c.ActionCtx == nil {
+		return app.runAction(before, DefaultAction, after, flags, args)
 	}
 
 	// Run action if there is one.
-	return c.Action(flags, args)
+	return app.runAction(before, action(app.ctx, c.Action, c.ActionCtx), after, flags, args)
 }
 
-func (c *Command) help(name string) string {
-	var sb strings.Builder
+// flags returns the command's flags, including those of its flag groups and its persistent flags.
+func (c *Command) flags() FlagSet {
+	flags := append(FlagSet{}, c.Flags...)
+	for _, group := range c.FlagGroups {
+		flags = append(flags, group.Flags...)
+	}
+	return append(flags, c.PersistentFlags...)
+}
+
+func (c *Command) isDeprecated() bool {
+	return c.Deprecated != "" || c.RemoveInVersion != ""
+}
+
+// aliases returns the command's aliases, leaving out hidden names.
+func (c *Command) aliases() []string {
+	aliases := make([]string, 0, len(c.Names)-1)
+	for _, name := range c.Names[1:] {
+		hidden := false
+		for _, hiddenName := range c.HiddenNames {
+			if name == hiddenName {
+				hidden = true
+				break
+			}
+		}
+		if !hidden {
+			aliases = append(aliases, name)
+		}
+	}
+	return aliases
+}
+
+// usage returns how to use the command, from Usage or its default.
+func (c *Command) usage() string {
+	if c.Usage != "" {
+		return expandUsage(c.Usage, c.flags(), c.Args, c.Commands)
+	}
+	usage := "[flags and values...] " + argsUsage(c.Args)
+	if len(c.Commands) >= 1 {
+		usage = "[command] " + usage
+	}
+	return usage
+}
+
+func (c *Command) help(app *Clippy, path string, all bool) string {
+	var sections helpSections
+	hc := app.helpConfig()
 
 	// NAME
-	sb.WriteString("NAME:\n")
-	sb.WriteString("\t" + name + " " + c.Names[0])
-	sb.WriteString("\n\n")
+	sections.add("NAME", hc.Indent+path+"\n")
 
 	// DESCRIPTION
 	if c.Description != "" {
-		sb.WriteString("DESCRIPTION:\n")
-		sb.WriteString("\t" + c.Description + "\n\n")
+		sections.add("DESCRIPTION", hc.paragraph(c.Description))
 	}
 
 	// USAGE
-	sb.WriteString("USAGE:\n")
-	usage := "[flags and values...] [arguments...]"
-	if c.Usage != "" {
-		usage = c.Usage
+	sections.add("USAGE", hc.Indent+path+" "+c.usage()+"\n")
+
+	// INPUT AND OUTPUT
+	if c.ReadsStdin || c.BinaryStdout {
+		var body string
+		if c.ReadsStdin {
+			body += hc.paragraph("Reads input from stdin.")
+		}
+		if c.BinaryStdout {
+			body += hc.paragraph("Writes binary data to stdout, so redirect it to a file or pipe.")
+		}
+		sections.add("INPUT AND OUTPUT", body)
 	}
-	sb.WriteString("\t" + name + " " + c.Names[0] + " " + usage + "\n\n")
 
-	// FLAGS
-	if len(c.Flags) >= 1 {
-		sb.WriteString("FLAG")
-		if len(c.Flags) > 1 {
-			sb.WriteString("S:\n")
-		} else {
-			sb.WriteString(":\n")
+	// COMMAND(S)
+	commands := c.Commands.visible()
+	if len(commands) >= 1 {
+		sections.add(plural("COMMAND", len(commands)), commands.help(hc))
+	}
+
+	// FLAG(S)
+	if flags := c.Flags.visible(); len(flags) >= 1 {
+		sections.add(plural("FLAG", len(flags)), flags.help(hc, all))
+	}
+
+	// FLAG GROUPS
+	for _, group := range c.FlagGroups {
+		if flags := group.Flags.visible(); len(flags) >= 1 {
+			sections.add(strings.ToUpper(group.Name)+" "+plural("FLAG", len(flags)), flags.help(hc, all))
 		}
-		sb.WriteString(c.Flags.help("\t"))
-		sb.WriteRune('\n')
 	}
 
-	return strings.TrimRight(sb.String(), "\n")
+	// PERSISTENT FLAG(S)
+	if flags := c.PersistentFlags.visible(); len(flags) >= 1 {
+		sections.add(plural("PERSISTENT FLAG", len(flags)), flags.help(hc, all))
+	}
+
+	// INHERITED FLAG(S)
+	fs := c.flags()
+	if inherited := fs.inherit(app.inheritedFlags(c))[len(fs):].visible(); len(inherited) >= 1 {
+		sections.add(plural("INHERITED FLAG", len(inherited)), inherited.help(hc, all))
+	}
+
+	// EXAMPLE(S)
+	if len(c.Examples) >= 1 {
+		sections.add(plural("EXAMPLE", len(c.Examples)), examplesHelp(hc, c.Examples))
+	}
+
+	// EXIT CODES
+	if len(c.ExitCodes) >= 1 {
+		sections.add("EXIT CODES", exitCodesHelp(hc, c.ExitCodes))
+	}
+
+	// DOCUMENTATION
+	fs = fs.visible()
+	if docs := commands.docs(hc.Indent) + fs.docs(hc.Indent); all && (c.DocsURL != "" || docs != "") {
+		if c.DocsURL != "" {
+			docs = hc.Indent + c.DocsURL + "\n" + docs
+		}
+		sections.add("DOCUMENTATION", docs)
+	}
+
+	return app.renderHelp(c.HelpTemplate, &HelpData{Name: path, Sections: sections, Program: app, Command: c})
 }
 
 // CommandSet is a list of Commands.
@@ -133,22 +361,87 @@ func (cs *CommandSet) get(name string) *Command {
 	return nil
 }
 
-func (cs *CommandSet) help(indent string) string {
-	var sb strings.Builder
+// ancestors returns the commands in the set that target is nested in, outermost first, or nil if it is not nested in any.
+func (cs CommandSet) ancestors(target *Command) []*Command {
+	for _, command := range cs {
+		if command.Commands.contains(target) {
+			return append([]*Command{command}, command.Commands.ancestors(target)...)
+		}
+	}
+	return nil
+}
 
-	var width int
-	var names []string
-	for _, cmd := range *cs {
-		name := strings.Join(cmd.Names, ", ")
-		if l := len(name); l > width {
-			width = l
+// contains returns whether target is in the set or nested in one of its commands.
+func (cs CommandSet) contains(target *Command) bool {
+	for _, command := range cs {
+		if command == target || command.Commands.contains(target) {
+			return true
 		}
-		names = append(names, name)
 	}
+	return false
+}
 
-	for i, cmd := range *cs {
-		sb.WriteString(fmt.Sprintf("%s%-*s%s%s\n", indent, width, names[i], indent, cmd.Description))
+// visible returns the commands in the set that are not hidden.
+func (cs CommandSet) visible() CommandSet {
+	visible := make(CommandSet, 0, len(cs))
+	for _, command := range cs {
+		if !command.Hidden {
+			visible = append(visible, command)
+		}
+	}
+	return visible
+}
+
+// walkVisible is like walk, but leaves out hidden commands and their subcommands.
+func (cs CommandSet) walkVisible(path string, fn func(path string, command *Command)) {
+	for _, command := range cs.visible() {
+		commandPath := path + " " + command.Names[0]
+		fn(commandPath, command)
+		command.Commands.walkVisible(commandPath, fn)
+	}
+}
+
+// walkMounted is like walkVisible, but also walks the commands of mounted programs, such as "app sub build".
+// The mounted programs' own flags and examples are on their commands' mounted field.
+func (cs CommandSet) walkMounted(path string, fn func(path string, command *Command)) {
+	for _, command := range cs.visible() {
+		commandPath := path + " " + command.Names[0]
+		fn(commandPath, command)
+		if command.mounted != nil {
+			command.mounted.Commands.walkMounted(commandPath, fn)
+		} else {
+			command.Commands.walkMounted(commandPath, fn)
+		}
 	}
+}
 
+// walk calls fn for each command in the set and, recursively, their subcommands.
+// The path is how the set's commands are invoked, such as "app" or "app remote".
+func (cs *CommandSet) walk(path string, fn func(path string, command *Command)) {
+	for _, command := range *cs {
+		commandPath := path + " " + command.Names[0]
+		fn(commandPath, command)
+		command.Commands.walk(commandPath, fn)
+	}
+}
+
+func (cs *CommandSet) docs(indent string) string {
+	var sb strings.Builder
+	for _, cmd := range *cs {
+		if cmd.DocsURL != "" {
+			sb.WriteString(indent + cmd.Names[0] + ": " + cmd.DocsURL + "\n")
+		}
+	}
 	return sb.String()
 }
+
+func (cs *CommandSet) help(hc *HelpConfig) string {
+	entries := make([]helpEntry, len(*cs))
+	for i, cmd := range *cs {
+		entries[i] = helpEntry{name: cmd.Names[0], aliases: cmd.aliases(), description: cmd.Description}
+		if cmd.isDeprecated() {
+			entries[i].description = "[deprecated] " + entries[i].description
+		}
+	}
+	return hc.table(entries)
+}
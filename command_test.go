@@ -0,0 +1,87 @@
+package clippy_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+	"github.com/patrickmcnamara/clippy/clippytest"
+)
+
+func TestSuppressFlags(t *testing.T) {
+	newApp := func() *clippy.Clippy {
+		return &clippy.Clippy{
+			Name:    "app",
+			Version: "1.0.0",
+			Flags:   clippy.FlagSet{{Name: "verbose", Kind: clippy.BoolKind, Description: "log more"}, {Name: "region", Description: "region to use"}},
+			Commands: clippy.CommandSet{
+				{Names: []string{"build"}, Action: clippy.DefaultAction},
+				{Names: []string{"version"}, SuppressFlags: []string{"region"}, Action: clippy.DefaultAction},
+				{
+					Names:           []string{"remote"},
+					SuppressFlags:   []string{"verbose"},
+					PersistentFlags: clippy.FlagSet{{Name: "remote", Description: "remote to use"}},
+					Commands: clippy.CommandSet{
+						{Names: []string{"add"}, Action: clippy.DefaultAction},
+						{Names: []string{"rm"}, SuppressFlags: []string{"remote"}, Action: clippy.DefaultAction},
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		params  []string
+		wantErr string
+	}{
+		{params: []string{"build", "--verbose", "--region", "eu"}},
+		{params: []string{"version", "--verbose"}},
+		{params: []string{"version", "--region", "eu"}, wantErr: `argument 2: "--region": unknown flag`},
+		{params: []string{"--region", "eu", "version"}, wantErr: `"--region": unknown flag`},
+		{params: []string{"remote", "add", "--remote", "origin", "--region", "eu"}},
+		{params: []string{"remote", "add", "--verbose"}, wantErr: `argument 3: "--verbose": unknown flag`},
+		{params: []string{"remote", "rm", "--remote", "origin"}, wantErr: `argument 3: "--remote": unknown flag`},
+	}
+	for _, test := range tests {
+		_, _, _, err := clippytest.Execute(newApp(), test.params...)
+		if test.wantErr == "" && err != nil {
+			t.Errorf("%q: %v", test.params, err)
+		} else if test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)) {
+			t.Errorf("%q: got error %v, want %q", test.params, err, test.wantErr)
+		}
+	}
+
+	// Suppressed flags are left out of help and completion.
+	helps := []struct {
+		params  []string
+		want    string
+		notWant string
+	}{
+		{[]string{"build", "--help"}, "--region", ""},
+		{[]string{"version", "--help"}, "--verbose", "--region"},
+		{[]string{"remote", "add", "--help"}, "--remote", "--verbose"},
+		{[]string{"remote", "rm", "--help"}, "--region", "--remote"},
+	}
+	for _, test := range helps {
+		stdout, _, _, err := clippytest.Execute(newApp(), test.params...)
+		if err != nil {
+			t.Errorf("%q: %v", test.params, err)
+		} else if !strings.Contains(stdout, test.want) || test.notWant != "" && strings.Contains(stdout, test.notWant) {
+			t.Errorf("%q: help should contain %q and not %q:\n%s", test.params, test.want, test.notWant, stdout)
+		}
+	}
+	script, err := newApp().Completion("bash")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "'app version') COMPREPLY=($(compgen -W '--verbose --help -h'"; !strings.Contains(script, want) {
+		t.Errorf("completion does not contain %q:\n%s", want, script)
+	}
+
+	// Only inherited flags can be suppressed.
+	app := newApp()
+	app.Commands[0].SuppressFlags = []string{"remote"}
+	if err := app.Check(); err == nil || err.Error() != `command "app build" suppresses flag "remote", which it does not inherit` {
+		t.Errorf("got error %v, want one for suppressing a flag that is not inherited", err)
+	}
+}
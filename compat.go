@@ -0,0 +1,27 @@
+package clippy
+
+import "fmt"
+
+// checkSupported checks that the program's version is within the versions supported by something, such as a command from another component.
+// The what describes it, for example `command "app sub"`. The minVersion and maxVersion are inclusive, and either can be empty for no limit.
+func (c *Clippy) checkSupported(what, minVersion, maxVersion string) error {
+	if minVersion != "" {
+		cmp, err := CompareVersions(c.Version, minVersion)
+		if err != nil {
+			return fmt.Errorf("cannot check minimum version of %s: %v", what, err)
+		}
+		if cmp < 0 {
+			return fmt.Errorf("%s needs version %s or later, not %s", what, minVersion, c.Version)
+		}
+	}
+	if maxVersion != "" {
+		cmp, err := CompareVersions(c.Version, maxVersion)
+		if err != nil {
+			return fmt.Errorf("cannot check maximum version of %s: %v", what, err)
+		}
+		if cmp > 0 {
+			return fmt.Errorf("%s supports up to version %s, not %s", what, maxVersion, c.Version)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+package clippy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// completionNode is the program or a command, with the words that can be completed after it.
+type completionNode struct {
+	path   string            // path is how the node is invoked, such as "app remote".
+	words  []string          // words are the names of the node's subcommands and flags.
+	steps  []completionStep  // steps lead from the node to its subcommands.
+	values []completionValue // values are how the values of the node's flags are completed.
+}
+
+// completionValue is how the value of a flag is completed after any of its names.
+type completionValue struct {
+	long, short []string // long and short are the flag's names and aliases, without dashes.
+	completion  string   // completion is the flag's Completion, defaulting to "files", or "choices".
+	choices     []string // choices are the flag's Choices.
+}
+
+// completionStep is a name that leads to a subcommand, such as an alias.
+type completionStep struct {
+	name string
+	path string
+}
+
+// Completion returns a shell completion script for the program, covering its commands, their aliases and their flags.
+// The shell is "bash", "zsh" or "fish".
+func (c *Clippy) Completion(shell string) (string, error) {
+	var nodes []completionNode
+	addCompletions(&nodes, c.Name, c.Flags, []string{"--help", "-h", "--version", "-v"}, c.Commands, c.Flags)
+
+	fn := "_" + shellIdentifier(c.Name)
+
+	switch shell {
+	case "bash":
+		return bashCompletion(c.Name, fn, nodes), nil
+	case "zsh":
+		return zshCompletion(c.Name, fn, nodes), nil
+	case "fish":
+		return fishCompletion(c.Name, fn, nodes), nil
+	}
+	return "", fmt.Errorf("unsupported shell: %q", shell)
+}
+
+// CompletionCommand returns a "completion" command that prints a shell completion script using Completion.
+// It can be added to the program's Commands.
+func (c *Clippy) CompletionCommand() *Command {
+	return &Command{
+		Names:       []string{"completion"},
+		Description: "print a shell completion script for bash, zsh or fish",
+		Args:        []string{"SHELL"},
+		Action: func(flags Flags, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("missing shell: use \"bash\", \"zsh\" or \"fish\"")
+			}
+			script, err := c.Completion(args[0])
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprint(c.stdout(), script)
+			return err
+		},
+	}
+}
+
+// addCompletions adds the node invoked by path, with the flags in fs and builtins, and recursively its subcommands, to nodes.
+// The subcommands inherit the global flags and the persistent flags of the commands they are nested in. Deprecated and hidden commands and flags are left out.
+func addCompletions(nodes *[]completionNode, path string, fs FlagSet, builtins []string, cs CommandSet, global FlagSet) {
+	node := completionNode{path: path, values: completionValues(fs)}
+	for _, command := range cs {
+		if command.isDeprecated() || command.Hidden {
+			continue
+		}
+		commandPath := path + " " + command.Names[0]
+		for _, name := range append([]string{command.Names[0]}, command.aliases()...) {
+			node.words = append(node.words, name)
+			node.steps = append(node.steps, completionStep{name: name, path: commandPath})
+		}
+	}
+	node.words = append(node.words, completionFlags(fs, builtins...)...)
+	*nodes = append(*nodes, node)
+
+	for _, command := range cs {
+		if command.isDeprecated() || command.Hidden {
+			continue
+		}
+		commandPath := path + " " + command.Names[0]
+		if command.mounted != nil {
+			addCompletions(nodes, commandPath, command.mounted.Flags, []string{"--help", "-h", "--version", "-v"}, command.mounted.Commands, command.mounted.Flags)
+		} else {
+			fs, inherited := command.flags(), global.without(command.SuppressFlags)
+			addCompletions(nodes, commandPath, fs.inherit(inherited), []string{"--help", "-h"}, command.Commands, append(append(FlagSet{}, command.PersistentFlags...), inherited...))
+		}
+	}
+}
+
+// completionFlags returns the names and aliases of the flags in fs that are not deprecated or hidden, followed by builtins.
+func completionFlags(fs FlagSet, builtins ...string) []string {
+	var words []string
+	for _, flag := range fs {
+		if flag.isDeprecated() || flag.Hidden {
+			continue
+		}
+		words = append(words, "--"+flag.Name)
+		for _, alias := range flag.LongAliases {
+			words = append(words, "--"+alias)
+		}
+		for _, alias := range flag.aliases() {
+			words = append(words, "-"+string(alias))
+		}
+	}
+	return append(words, builtins...)
+}
+
+// completionValues returns how the values of the flags in fs that take one and are not deprecated or hidden are completed.
+func completionValues(fs FlagSet) []completionValue {
+	var values []completionValue
+	for _, flag := range fs {
+		if flag.isDeprecated() || flag.Hidden || !flag.Kind.takesValue() {
+			continue
+		}
+		value := completionValue{long: append([]string{flag.Name}, flag.LongAliases...), completion: flag.Completion, choices: flag.Choices}
+		for _, alias := range flag.aliases() {
+			value.short = append(value.short, string(alias))
+		}
+		if value.completion == "" && len(flag.Choices) >= 1 {
+			value.completion = "choices"
+		} else if value.completion == "" {
+			value.completion = "files"
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// patterns returns the case patterns matching the flag's names after path, such as "'app --file'|'app -f'".
+func (v completionValue) patterns(path string) string {
+	var patterns []string
+	for _, name := range v.long {
+		patterns = append(patterns, quote(path+" --"+name))
+	}
+	for _, name := range v.short {
+		patterns = append(patterns, quote(path+" -"+name))
+	}
+	return strings.Join(patterns, "|")
+}
+
+func bashCompletion(name, fn string, nodes []completionNode) string {
+	var sb strings.Builder
+	sb.WriteString(fn + "() {\n")
+	sb.WriteString("\tlocal cur prev cmdpath word\n")
+	sb.WriteString("\tcur=\"${COMP_WORDS[COMP_CWORD]}\"\n")
+	sb.WriteString("\tprev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n")
+	sb.WriteString("\tcmdpath=" + quote(name) + "\n")
+	sb.WriteString("\tfor word in \"${COMP_WORDS[@]:1:COMP_CWORD-1}\"; do\n")
+	sb.WriteString("\t\tcase \"$cmdpath $word\" in\n")
+	for _, node := range nodes {
+		for _, step := range node.steps {
+			sb.WriteString("\t\t" + quote(node.path+" "+step.name) + ") cmdpath=" + quote(step.path) + " ;;\n")
+		}
+	}
+	sb.WriteString("\t\tesac\n")
+	sb.WriteString("\tdone\n")
+	sb.WriteString("\tcase \"$cmdpath $prev\" in\n")
+	for _, node := range nodes {
+		for _, value := range node.values {
+			var reply string
+			switch value.completion {
+			case "files":
+				reply = "COMPREPLY=($(compgen -f -- \"$cur\"))"
+			case "dirs":
+				reply = "COMPREPLY=($(compgen -d -- \"$cur\"))"
+			case "hosts":
+				reply = "COMPREPLY=($(compgen -A hostname -- \"$cur\"))"
+			case "none":
+				reply = "COMPREPLY=()"
+			case "choices":
+				reply = "COMPREPLY=($(compgen -W " + quote(strings.Join(value.choices, " ")) + " -- \"$cur\"))"
+			default:
+				reply = "COMPREPLY=($(compgen -W \"$(" + Quote(append([]string{name}, strings.Fields(value.completion)...)...) + " 2>/dev/null)\" -- \"$cur\"))"
+			}
+			sb.WriteString("\t" + value.patterns(node.path) + ") " + reply + "; return ;;\n")
+		}
+	}
+	sb.WriteString("\tesac\n")
+	sb.WriteString("\tcase \"$cmdpath\" in\n")
+	for _, node := range nodes {
+		sb.WriteString("\t" + quote(node.path) + ") COMPREPLY=($(compgen -W " + quote(strings.Join(node.words, " ")) + " -- \"$cur\")) ;;\n")
+	}
+	sb.WriteString("\tesac\n")
+	sb.WriteString("}\n")
+	sb.WriteString("complete -F " + fn + " " + quote(name) + "\n")
+	return sb.String()
+}
+
+func zshCompletion(name, fn string, nodes []completionNode) string {
+	var sb strings.Builder
+	sb.WriteString("#compdef " + name + "\n\n")
+	sb.WriteString(fn + "() {\n")
+	sb.WriteString("\tlocal cmdpath word\n")
+	sb.WriteString("\tcmdpath=" + quote(name) + "\n")
+	sb.WriteString("\tfor word in \"${(@)words[2,CURRENT-1]}\"; do\n")
+	sb.WriteString("\t\tcase \"$cmdpath $word\" in\n")
+	for _, node := range nodes {
+		for _, step := range node.steps {
+			sb.WriteString("\t\t" + quote(node.path+" "+step.name) + ") cmdpath=" + quote(step.path) + " ;;\n")
+		}
+	}
+	sb.WriteString("\t\tesac\n")
+	sb.WriteString("\tdone\n")
+	sb.WriteString("\tcase \"$cmdpath ${words[CURRENT-1]}\" in\n")
+	for _, node := range nodes {
+		for _, value := range node.values {
+			var reply string
+			switch value.completion {
+			case "files":
+				reply = "_files"
+			case "dirs":
+				reply = "_files -/"
+			case "hosts":
+				reply = "_hosts"
+			case "none":
+				reply = ":"
+			case "choices":
+				reply = "compadd -- " + strings.Join(quoteAll(value.choices), " ")
+			default:
+				reply = "compadd -- ${(f)\"$(" + Quote(append([]string{name}, strings.Fields(value.completion)...)...) + " 2>/dev/null)\"}"
+			}
+			sb.WriteString("\t" + value.patterns(node.path) + ") " + reply + "; return ;;\n")
+		}
+	}
+	sb.WriteString("\tesac\n")
+	sb.WriteString("\tcase \"$cmdpath\" in\n")
+	for _, node := range nodes {
+		sb.WriteString("\t" + quote(node.path) + ") compadd -- " + strings.Join(quoteAll(node.words), " ") + " ;;\n")
+	}
+	sb.WriteString("\tesac\n")
+	sb.WriteString("}\n\n")
+	sb.WriteString("compdef " + fn + " " + quote(name) + "\n")
+	return sb.String()
+}
+
+func fishCompletion(name, fn string, nodes []completionNode) string {
+	var sb strings.Builder
+	sb.WriteString("function " + fn + "_path\n")
+	sb.WriteString("\tset -l cmdpath " + quote(name) + "\n")
+	sb.WriteString("\tfor word in (commandline -opc)[2..-1]\n")
+	sb.WriteString("\t\tswitch \"$cmdpath $word\"\n")
+	for _, node := range nodes {
+		for _, step := range node.steps {
+			sb.WriteString("\t\t\tcase " + quote(node.path+" "+step.name) + "\n")
+			sb.WriteString("\t\t\t\tset cmdpath " + quote(step.path) + "\n")
+		}
+	}
+	sb.WriteString("\t\tend\n")
+	sb.WriteString("\tend\n")
+	sb.WriteString("\techo $cmdpath\n")
+	sb.WriteString("end\n\n")
+	sb.WriteString("complete -c " + quote(name) + " -f\n")
+	for _, node := range nodes {
+		condition := quote("test (" + fn + "_path) = " + quote(node.path))
+		sb.WriteString("complete -c " + quote(name) + " -n " + condition + " -a " + quote(strings.Join(node.words, " ")) + "\n")
+		for _, value := range node.values {
+			var options string
+			for _, long := range value.long {
+				options += " -l " + quote(long)
+			}
+			for _, short := range value.short {
+				options += " -s " + quote(short)
+			}
+			switch value.completion {
+			case "files":
+				options += " -r -F"
+			case "dirs":
+				options += " -x -a '(__fish_complete_directories)'"
+			case "hosts":
+				options += " -x -a '(__fish_print_hostnames)'"
+			case "none":
+				options += " -x"
+			case "choices":
+				options += " -x -a " + quote(strings.Join(value.choices, " "))
+			default:
+				options += " -x -a " + quote("("+Quote(append([]string{name}, strings.Fields(value.completion)...)...)+" 2>/dev/null)")
+			}
+			sb.WriteString("complete -c " + quote(name) + " -n " + condition + options + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// shellIdentifier returns name with the characters that cannot be in a shell function name replaced by underscores.
+func shellIdentifier(name string) string {
+	return strings.Map(func(char rune) rune {
+		if char == '-' || char == '.' {
+			return '_'
+		}
+		return char
+	}, name)
+}
+
+// quoteAll quotes each of words for a shell.
+func quoteAll(words []string) []string {
+	quoted := make([]string, len(words))
+	for i, word := range words {
+		quoted[i] = quote(word)
+	}
+	return quoted
+}
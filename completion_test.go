@@ -0,0 +1,133 @@
+package clippy_test
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+)
+
+func newCompletingApp() *clippy.Clippy {
+	return &clippy.Clippy{
+		Name:    "app",
+		Version: "1.0.0",
+		Flags:   clippy.FlagSet{{Name: "config", Alias: 'c'}, {Name: "verbose", Kind: clippy.BoolKind}},
+		Commands: clippy.CommandSet{{
+			Names: []string{"deploy"},
+			Flags: clippy.FlagSet{
+				{Name: "dir", Completion: "dirs"},
+				{Name: "name", Completion: "none"},
+				{Name: "format", Choices: []string{"json", "text"}},
+				{Name: "profile", Alias: 'p', Completion: "profiles list"},
+			},
+			Action: clippy.DefaultAction,
+		}},
+	}
+}
+
+func TestCompletionValues(t *testing.T) {
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash is not installed")
+	}
+	script, err := newCompletingApp().Completion("bash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Complete in a directory with a file and a directory, with a fake program on the PATH that prints profiles.
+	dir, err := ioutil.TempDir("", "clippy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for path, content := range map[string]string{
+		"bin/app":   "#!/bin/sh\n[ \"$1 $2\" = 'profiles list' ] && printf 'staging\\nproduction\\n'\n",
+		"work/file": "",
+	} {
+		os.MkdirAll(filepath.Join(dir, filepath.Dir(path)), 0755)
+		if err := ioutil.WriteFile(filepath.Join(dir, path), []byte(content), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	os.Mkdir(filepath.Join(dir, "work", "sub"), 0755)
+
+	tests := []struct {
+		words []string
+		want  string
+	}{
+		{[]string{"app", ""}, "deploy --config -c --verbose --help -h --version -v"},
+		{[]string{"app", "d"}, "deploy"},
+		{[]string{"app", "--config", ""}, "file sub"},
+		{[]string{"app", "deploy", "--dir", ""}, "sub"},
+		{[]string{"app", "deploy", "--name", ""}, ""},
+		{[]string{"app", "deploy", "--format", ""}, "json text"},
+		{[]string{"app", "deploy", "--format", "j"}, "json"},
+		{[]string{"app", "deploy", "-p", ""}, "staging production"},
+		{[]string{"app", "deploy", "--verbose", ""}, "--dir --name --format --profile -p --config -c --verbose --help -h"},
+	}
+	for _, test := range tests {
+		cmd := exec.Command(bash, "-c", script+`
+COMP_WORDS=("$@")
+COMP_CWORD=$(($# - 1))
+_app
+echo "${COMPREPLY[*]}"`, "bash")
+		cmd.Args = append(cmd.Args, test.words...)
+		cmd.Dir = filepath.Join(dir, "work")
+		cmd.Env = append(os.Environ(), "PATH="+filepath.Join(dir, "bin")+":"+os.Getenv("PATH"))
+		out, err := cmd.Output()
+		if err != nil {
+			t.Errorf("%q: %v", test.words, err)
+			continue
+		}
+		if got := strings.Join(strings.Fields(string(out)), " "); got != test.want {
+			t.Errorf("%q: completed %q, want %q", test.words, got, test.want)
+		}
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"zsh", []string{
+			"'app deploy --dir') _files -/; return ;;",
+			"'app deploy --format') compadd -- json text; return ;;",
+			`'app deploy --profile'|'app deploy -p') compadd -- ${(f)"$(app profiles list 2>/dev/null)"}; return ;;`,
+			"'app --config'|'app -c') _files; return ;;",
+		}},
+		{"fish", []string{
+			`-l dir -x -a '(__fish_complete_directories)'`,
+			`-l name -x` + "\n",
+			`-l format -x -a 'json text'`,
+			`-l profile -s p -x -a '(app profiles list 2>/dev/null)'`,
+			`-l config -s c -r -F`,
+		}},
+	}
+	for _, test := range tests {
+		script, err := newCompletingApp().Completion(test.shell)
+		if err != nil {
+			t.Errorf("%s: %v", test.shell, err)
+			continue
+		}
+		for _, want := range test.want {
+			if !strings.Contains(script, want) {
+				t.Errorf("%s: script does not contain %q:\n%s", test.shell, want, script)
+			}
+		}
+	}
+
+	app := newCompletingApp()
+	app.Flags[1].Completion = "files"
+	if _, err := app.Completion("bash"); err != nil {
+		t.Fatal(err)
+	}
+	if err := app.Check(); err == nil || err.Error() != `bool flag "verbose" cannot complete a value` {
+		t.Errorf("got error %v, want one for completing a bool flag", err)
+	}
+}
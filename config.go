@@ -0,0 +1,154 @@
+package clippy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// configSection is a section of a config file, with the values of flags and the sections of commands.
+// For example, {"verbose": true, "build": {"release": true}} sets "--verbose" and "app build --release".
+type configSection struct {
+	values   map[string][]string
+	sections map[string]*configSection
+}
+
+// loadConfig removes the "--config" global flag from params and reads the config file it gives, or ConfigFile if it was not given.
+// It is not an error for ConfigFile not to exist, since the user may not have written one.
+func (c *Clippy) loadConfig(params []string) ([]string, *configSection, error) {
+	params, path, err := c.removeValueParam(params, "--config")
+	if err != nil {
+		return nil, nil, err
+	}
+	given := path != ""
+	if !given {
+		if path = c.ConfigFile; !filepath.IsAbs(path) {
+			dir, err := os.UserConfigDir()
+			if err != nil {
+				return params, nil, nil
+			}
+			path = filepath.Join(dir, c.Name, path)
+		}
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !given {
+		return params, nil, nil
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("cannot read config file: %v", err)
+	}
+	var m map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+	if err := d.Decode(&m); err != nil {
+		return nil, nil, fmt.Errorf("invalid config file %q: %v", path, err)
+	}
+	config, err := newConfigSection(m)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid config file %q: %v", path, err)
+	}
+	return params, config, nil
+}
+
+func newConfigSection(m map[string]interface{}) (*configSection, error) {
+	section := &configSection{values: make(map[string][]string), sections: make(map[string]*configSection)}
+	for key, value := range m {
+		switch value := value.(type) {
+		case nil:
+		case map[string]interface{}:
+			sub, err := newConfigSection(value)
+			if err != nil {
+				return nil, err
+			}
+			section.sections[key] = sub
+		case []interface{}:
+			list := make([]string, len(value))
+			for i, v := range value {
+				s, ok := configScalar(v)
+				if !ok {
+					return nil, fmt.Errorf("list %q can only hold strings, numbers and booleans", key)
+				}
+				list[i] = s
+			}
+			section.values[key] = list
+		default:
+			s, ok := configScalar(value)
+			if !ok {
+				return nil, fmt.Errorf("unexpected value for %q", key)
+			}
+			section.values[key] = []string{s}
+		}
+	}
+	return section, nil
+}
+
+// configScalar returns a string, number or boolean from a config file as a flag value.
+func configScalar(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case bool:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
+
+// profilesKey is the section of the config file that holds its profiles.
+const profilesKey = "profiles"
+
+// selectProfile removes the "--profile" global flag from params and returns the name of the profile it gives, or that ProfileEnv gives if it was not given.
+// It is an error if the config file has no such profile.
+func (c *Clippy) selectProfile(params []string) ([]string, string, error) {
+	params, name, err := c.removeValueParam(params, "--profile")
+	if err != nil {
+		return nil, "", err
+	}
+	if name == "" {
+		name = os.Getenv(c.ProfileEnv)
+	}
+	if name != "" && c.config.profile(name) == nil {
+		return nil, "", fmt.Errorf("unknown profile: %q", name)
+	}
+	return params, name, nil
+}
+
+// profile returns the section of the named profile, or nil if there is no such profile.
+func (s *configSection) profile(name string) *configSection {
+	if s == nil || s.sections[profilesKey] == nil {
+		return nil
+	}
+	return s.sections[profilesKey].sections[name]
+}
+
+// configValue returns the values of the named flag in the config file, from the section of the command being run or, failing that, the sections it is nested in.
+// The sections of the selected profile are looked in first.
+func (c *Clippy) configValue(name string) ([]string, bool) {
+	if c.config == nil {
+		return nil, false
+	}
+	roots := []*configSection{c.config}
+	if c.configProfile != "" {
+		roots = []*configSection{c.config.profile(c.configProfile), c.config}
+	}
+	for _, root := range roots {
+		sections := []*configSection{root}
+		for _, key := range c.configKeys {
+			section := sections[len(sections)-1].sections[key]
+			if section == nil {
+				break
+			}
+			sections = append(sections, section)
+		}
+		for i := len(sections) - 1; i >= 0; i-- {
+			if values, ok := sections[i].values[name]; ok {
+				return values, true
+			}
+		}
+	}
+	return nil, false
+}
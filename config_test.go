@@ -0,0 +1,93 @@
+package clippy_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+	"github.com/patrickmcnamara/clippy/clippytest"
+)
+
+func TestConfigProfiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clippy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(config, []byte(`{
+		"region": "us",
+		"replicas": 1,
+		"deploy": {"replicas": 2},
+		"profiles": {
+			"staging": {"region": "eu"},
+			"production": {"region": "ap", "deploy": {"replicas": 5}}
+		}
+	}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var region string
+	var replicas int
+	app := &clippy.Clippy{
+		Name:         "app",
+		Version:      "1.0.0",
+		ConfigFile:   config,
+		ProfileEnv:   "APP_TEST_PROFILE",
+		PrintCmdFlag: true,
+		Flags:        clippy.FlagSet{{Name: "region"}, {Name: "replicas", Kind: clippy.IntKind}},
+		Commands: clippy.CommandSet{{
+			Names: []string{"deploy"},
+			Action: func(flags clippy.Flags, args []string) error {
+				region, replicas = flags.GetString("region"), flags.GetInt("replicas")
+				return nil
+			},
+		}},
+	}
+
+	tests := []struct {
+		env          string
+		params       []string
+		wantRegion   string
+		wantReplicas int
+		wantStderr   string
+		wantErr      string
+	}{
+		{params: []string{"deploy"}, wantRegion: "us", wantReplicas: 2},
+		{params: []string{"--profile", "staging", "deploy"}, wantRegion: "eu", wantReplicas: 2},
+		{params: []string{"deploy", "--profile=production"}, wantRegion: "ap", wantReplicas: 5},
+		{params: []string{"deploy", "--profile", "staging", "--region", "sa"}, wantRegion: "sa", wantReplicas: 2},
+		{env: "staging", params: []string{"deploy"}, wantRegion: "eu", wantReplicas: 2},
+		{env: "staging", params: []string{"--profile", "production", "deploy"}, wantRegion: "ap", wantReplicas: 5},
+		{env: "production", params: []string{"--print-command", "deploy"}, wantRegion: "ap", wantReplicas: 5, wantStderr: "app deploy --profile production\n"},
+		{params: []string{"--profile", "dev", "deploy"}, wantErr: `unknown profile: "dev"`},
+		{env: "dev", params: []string{"deploy"}, wantErr: `unknown profile: "dev"`},
+	}
+	for _, test := range tests {
+		os.Setenv("APP_TEST_PROFILE", test.env)
+		region, replicas = "", 0
+		_, stderr, _, err := clippytest.Execute(app, test.params...)
+		if test.wantErr != "" {
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("%s %q: got error %v, want %q", test.env, test.params, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: %v", test.env, test.params, err)
+		} else if region != test.wantRegion || replicas != test.wantReplicas {
+			t.Errorf("%s %q: got region %q and replicas %d, want %q and %d", test.env, test.params, region, replicas, test.wantRegion, test.wantReplicas)
+		} else if stderr != test.wantStderr {
+			t.Errorf("%s %q: got stderr %q, want %q", test.env, test.params, stderr, test.wantStderr)
+		}
+	}
+	os.Unsetenv("APP_TEST_PROFILE")
+
+	stdout, _, _, _ := clippytest.Execute(app, "--help")
+	if want := "--profile"; !strings.Contains(stdout, want) || !strings.Contains(stdout, "$APP_TEST_PROFILE") {
+		t.Errorf("help does not contain %q:\n%s", want, stdout)
+	}
+}
@@ -0,0 +1,32 @@
+package clippy
+
+import "fmt"
+
+// checkDeprecated checks that something deprecated has not outlived the version it was to be removed in.
+// The what describes it, for example `flag "colour"`.
+func (c *Clippy) checkDeprecated(what, removeInVersion string) error {
+	if removeInVersion == "" {
+		return nil
+	}
+	cmp, err := CompareVersions(c.Version, removeInVersion)
+	if err != nil {
+		return fmt.Errorf("cannot check removal version of %s: %v", what, err)
+	}
+	if cmp >= 0 {
+		return fmt.Errorf("%s should have been removed in version %s", what, removeInVersion)
+	}
+	return nil
+}
+
+// warnDeprecated warns that something deprecated was used.
+// The what describes it, for example `flag "colour"`.
+func (c *Clippy) warnDeprecated(what, deprecated, removeInVersion string) {
+	msg := what + " is deprecated"
+	if removeInVersion != "" {
+		msg += " and will be removed in version " + removeInVersion
+	}
+	if deprecated != "" {
+		msg += ": " + deprecated
+	}
+	c.Warn("%s", msg)
+}
@@ -0,0 +1,79 @@
+package clippy
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// ConfigDir returns the program's configuration directory, creating it if it does not exist.
+// On Unix, it is $XDG_CONFIG_HOME/name or ~/.config/name.
+func (c *Clippy) ConfigDir() (string, error) {
+	return c.dir(os.UserConfigDir)
+}
+
+// CacheDir returns the program's cache directory, creating it if it does not exist.
+// On Unix, it is $XDG_CACHE_HOME/name or ~/.cache/name.
+func (c *Clippy) CacheDir() (string, error) {
+	return c.dir(os.UserCacheDir)
+}
+
+// DataDir returns the program's data directory, creating it if it does not exist.
+// On Unix, it is $XDG_DATA_HOME/name or ~/.local/share/name.
+func (c *Clippy) DataDir() (string, error) {
+	return c.dir(func() (string, error) { return userDir("XDG_DATA_HOME", ".local/share") })
+}
+
+// StateDir returns the program's state directory, creating it if it does not exist.
+// On Unix, it is $XDG_STATE_HOME/name or ~/.local/state/name.
+func (c *Clippy) StateDir() (string, error) {
+	return c.dir(func() (string, error) { return userDir("XDG_STATE_HOME", ".local/state") })
+}
+
+func (c *Clippy) dir(base func() (string, error)) (string, error) {
+	dir, err := base()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, c.Name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// userDir returns the user's base directory for data or state, following os.UserConfigDir for each OS.
+func userDir(env, home string) (string, error) {
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return dir, nil
+		}
+		return "", errors.New("%LocalAppData% is not defined")
+	case "darwin", "ios":
+		dir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "Library", "Application Support"), nil
+	case "plan9":
+		dir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "lib"), nil
+	}
+
+	if dir := os.Getenv(env); dir != "" {
+		if !filepath.IsAbs(dir) {
+			return "", errors.New("path in $" + env + " is relative")
+		}
+		return dir, nil
+	}
+	dir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, filepath.FromSlash(home)), nil
+}
@@ -0,0 +1,27 @@
+package clippy
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Dispatch runs the program busybox-style, where argv is the full argument list including the program name, such as os.Args.
+// If the program was invoked by the name of one of its commands, for example through a symlink called "build", that command is run as if it were its own program.
+// It then has its own help and version.
+// Otherwise, it is the same as calling Run with argv[1:].
+func (c *Clippy) Dispatch(argv []string) {
+	if len(argv) == 0 {
+		c.Run(argv)
+		return
+	}
+
+	name := strings.TrimSuffix(filepath.Base(argv[0]), ".exe")
+	if command := c.Commands.get(name); command != nil && name != c.Name {
+		c.persona = command
+		defer func() { c.persona = nil }()
+		c.Run(append([]string{name}, argv[1:]...))
+		return
+	}
+
+	c.Run(argv[1:])
+}
@@ -0,0 +1,75 @@
+package clippy_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+)
+
+// newMountingApp returns a program with a mounted program, for checking that docs cover it.
+func newMountingApp() *clippy.Clippy {
+	sub := &clippy.Clippy{
+		Name:     "sub",
+		Version:  "2.0.0",
+		Tagline:  "the mounted program",
+		Flags:    clippy.FlagSet{{Name: "sub-flag", Description: "a flag of the mounted program", DocsURL: "https://example.com/sub-flag"}},
+		Examples: []clippy.Example{{Cmd: "app sub --sub-flag x", Description: "use the mounted program"}},
+		Commands: clippy.CommandSet{
+			{Names: []string{"inner"}, Description: "a command of the mounted program", Flags: clippy.FlagSet{{Name: "inner-flag", Description: "a flag of the inner command"}}},
+		},
+	}
+	return &clippy.Clippy{
+		Name:     "app",
+		Version:  "1.0.0",
+		Commands: clippy.CommandSet{{Names: []string{"build"}, Description: "build it", DocsURL: "https://example.com/build"}, clippy.Mount(sub)},
+	}
+}
+
+func TestManMounted(t *testing.T) {
+	b, err := newMountingApp().Man()
+	if err != nil {
+		t.Fatal(err)
+	}
+	man := string(b)
+	for _, want := range []string{
+		".B app sub\n",
+		`\-\-sub\-flag`,
+		"See also https://example.com/sub\\-flag.",
+		"See also https://example.com/build.",
+		".B app sub inner\n",
+		`\-\-inner\-flag`,
+		"use the mounted program",
+	} {
+		if !strings.Contains(man, want) {
+			t.Errorf("man page does not contain %q:\n%s", want, man)
+		}
+	}
+}
+
+func TestMarkdownMounted(t *testing.T) {
+	pages, err := newMountingApp().Markdown()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		page string
+		want []string
+	}{
+		{"app.md", []string{"[`sub`](app-sub.md): the mounted program"}},
+		{"app-sub.md", []string{"# app sub", "Version 2.0.0.", "`--sub-flag`", "[More](https://example.com/sub-flag)", "[`inner`](app-sub-inner.md)", "Back to [app](app.md)."}},
+		{"app-sub-inner.md", []string{"# app sub inner", "`--inner-flag`", "Back to [app sub](app-sub.md)."}},
+	}
+	for _, test := range tests {
+		page, ok := pages[test.page]
+		if !ok {
+			t.Errorf("no page %q", test.page)
+			continue
+		}
+		for _, want := range test.want {
+			if !strings.Contains(string(page), want) {
+				t.Errorf("page %q does not contain %q:\n%s", test.page, want, page)
+			}
+		}
+	}
+}
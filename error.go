@@ -1,29 +1,186 @@
 package clippy
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"strconv"
 	"strings"
 )
 
 // ErrHandler is an error handler that handles an action, parsing, or setup error.
-// The name is the name of the program and the err is the error being handled.
-type ErrHandler func(name string, err error)
+// The w is the program's Stderr, the name is the name of the program and the err is the error being handled.
+type ErrHandler func(w io.Writer, name string, err error)
 
 var (
-	// ActionErrHandler handles errors the errors that actions may return.
-	ActionErrHandler ErrHandler = func(name string, err error) { defaultErrHandler(name, err, 1) }
+	// ActionErrHandler handles errors the errors that actions may return. By default, it exits with 1, or with the Code of an *ExitError.
+	ActionErrHandler ErrHandler = func(w io.Writer, name string, err error) { defaultErrHandler(w, name, err, 1) }
 	// ParseErrHandler handles errors the errors that may be returned when parsing.
-	ParseErrHandler ErrHandler = func(name string, err error) { defaultErrHandler(name, err, 2) }
+	ParseErrHandler ErrHandler = func(w io.Writer, name string, err error) { defaultErrHandler(w, name, err, 2) }
 	// SetupErrHandler handles errors the errors that may be returned when checking if the clippy is valid.
-	SetupErrHandler ErrHandler = func(name string, err error) { defaultErrHandler(name, err, 3) }
+	SetupErrHandler ErrHandler = func(w io.Writer, name string, err error) { defaultErrHandler(w, name, err, 3) }
 )
 
-func defaultErrHandler(name string, err error, exitCode int) {
+// SetupError is an error checking the program, as returned by RunE. It is handled by SetupErrHandler.
+type SetupError struct {
+	Err error
+}
+
+func (e *SetupError) Error() string { return e.Err.Error() }
+
+func (e *SetupError) Unwrap() error { return e.Err }
+
+// ParseError is an error parsing params, as returned by RunE. It is handled by ParseErrHandler.
+type ParseError struct {
+	Err   error
+	Param int    // Param is the position of the offending param in the params the program was run with, counting from 1, such as 3 for "--cont" in "app build x --cont". It is 0 if the error is not about a particular param or the param came from OptsEnv.
+	Token string // Token is the text of the offending param.
+}
+
+// Error returns the error, prefixed by the position and text of the offending param if there is one. For example, `argument 3: "--cont": unknown flag`.
+func (e *ParseError) Error() string {
+	if e.Param == 0 && e.Token != "" {
+		return fmt.Sprintf("%q: %v", e.Token, e.Err)
+	} else if e.Param == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("argument %d: %q: %v", e.Param, e.Token, e.Err)
+}
+
+func (e *ParseError) Unwrap() error { return e.Err }
+
+// ActionError is an error returned by an action, as returned by RunE. It is handled by ActionErrHandler.
+type ActionError struct {
+	Err error
+}
+
+func (e *ActionError) Error() string { return e.Err.Error() }
+
+func (e *ActionError) Unwrap() error { return e.Err }
+
+// parseError returns err as a *ParseError, keeping its position if it already is one.
+func parseError(err error) error {
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		return err
+	}
+	return &ParseError{Err: err}
+}
+
+// ExitError is an error that makes the program exit with Code rather than the code of its error handler.
+// If Err is nil, the program exits without printing anything, such as for NoArgsExitCode.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return "exit status " + strconv.Itoa(e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error { return e.Err }
+
+// Exit returns an error for an action to return that makes the program print msg, like any other error, and exit with code.
+// If msg is empty, the program exits without printing anything.
+func Exit(code int, msg string) error {
+	if msg == "" {
+		return &ExitError{Code: code}
+	}
+	return &ExitError{code, errors.New(msg)}
+}
+
+// brokenPipeExitCode is the exit code used when output cannot be written because the pipe it goes to is closed.
+// It is 128 plus the number of SIGPIPE, as shells report for a process killed by it.
+const brokenPipeExitCode = 141
+
+// handleErr handles err, as returned by RunE, with the matching error handler.
+func (c *Clippy) handleErr(err error) {
+	name, w := c.Name, c.stderr()
+	var (
+		setupErr  *SetupError
+		parseErr  *ParseError
+		actionErr *ActionError
+		exitErr   *ExitError
+	)
+	switch {
+	case err == nil:
+	case isBrokenPipe(err):
+		// The reader of the output has gone away, such as "app --help | head", so there is nobody to tell.
+		os.Exit(brokenPipeExitCode)
+	case errors.As(err, &exitErr) && exitErr.Err == nil:
+		os.Exit(exitErr.Code)
+	case errors.As(err, &setupErr):
+		SetupErrHandler(w, name, setupErr.Err)
+	case errors.As(err, &parseErr):
+		ParseErrHandler(w, name, parseErr)
+	case errors.As(err, &actionErr):
+		ActionErrHandler(w, name, actionErr.Err)
+	default:
+		ActionErrHandler(w, name, err)
+	}
+}
+
+// ExitStatus returns the exit status that the program exits with for err, as returned by RunE, when it is handled by the default error handlers.
+func ExitStatus(err error) int {
+	var (
+		setupErr *SetupError
+		parseErr *ParseError
+		exitErr  *ExitError
+	)
+	switch {
+	case err == nil:
+		return 0
+	case isBrokenPipe(err):
+		return brokenPipeExitCode
+	case errors.As(err, &exitErr):
+		return exitErr.Code
+	case errors.As(err, &setupErr):
+		return 3
+	case errors.As(err, &parseErr):
+		return 2
+	default:
+		return 1
+	}
+}
+
+func defaultErrHandler(w io.Writer, name string, err error, exitCode int) {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		exitCode = exitErr.Code
+	}
 	msg := err.Error()
 	if i := strings.IndexRune(msg, ':'); i != -1 && name != msg[:i] {
 		msg = name + ": " + msg
 	}
-	fmt.Fprintln(os.Stderr, msg)
+	fmt.Fprintln(w, msg)
 	os.Exit(exitCode)
 }
+
+// ExitCode describes an exit code that a program or command can exit with, for its help.
+type ExitCode struct {
+	Code        int    // Code is the exit code.
+	Description string // Description says when the code is used.
+}
+
+func checkExitCodes(exitCodes []ExitCode) error {
+	codes := make(map[int]struct{})
+	for _, exitCode := range exitCodes {
+		if _, ok := codes[exitCode.Code]; ok {
+			return fmt.Errorf("duplicate exit code: %d", exitCode.Code)
+		}
+		codes[exitCode.Code] = struct{}{}
+	}
+	return nil
+}
+
+func exitCodesHelp(hc *HelpConfig, exitCodes []ExitCode) string {
+	entries := make([]helpEntry, len(exitCodes))
+	for i, exitCode := range exitCodes {
+		entries[i] = helpEntry{name: strconv.Itoa(exitCode.Code), description: exitCode.Description}
+	}
+	return hc.table(entries)
+}
@@ -0,0 +1,64 @@
+package clippy_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+	"github.com/patrickmcnamara/clippy/clippytest"
+)
+
+func TestParseErrorPosition(t *testing.T) {
+	newApp := func() *clippy.Clippy {
+		return &clippy.Clippy{
+			Name:           "app",
+			Version:        "1.0.0",
+			OptsEnv:        "APP_TEST_OPTS",
+			HelpWidthFlag:  true,
+			NoWarningsFlag: true,
+			Flags:          clippy.FlagSet{{Name: "verbose", Kind: clippy.BoolKind}},
+			Commands: clippy.CommandSet{
+				{Names: []string{"build"}, Action: clippy.DefaultAction},
+				{Names: []string{"remote"}, Commands: clippy.CommandSet{{Names: []string{"add"}, Action: clippy.DefaultAction}}},
+				clippy.Mount(&clippy.Clippy{Name: "sub", Version: "1.0.0", Action: clippy.DefaultAction}),
+			},
+			PreParse: func(params []string) []string {
+				if len(params) >= 1 && params[0] == "b" {
+					params[0] = "build"
+				}
+				return params
+			},
+		}
+	}
+
+	tests := []struct {
+		env    string
+		params []string
+		param  int
+		token  string
+	}{
+		{params: []string{"--cont"}, param: 1, token: "--cont"},
+		{params: []string{"build", "x", "--cont"}, param: 3, token: "--cont"},
+		{params: []string{"--no-warnings", "build", "--cont"}, param: 3, token: "--cont"},
+		{params: []string{"--verbose", "remote", "add", "--cont"}, param: 4, token: "--cont"},
+		{params: []string{"remote", "--verbose", "add", "--help-width", "80", "--cont"}, param: 6, token: "--cont"},
+		{params: []string{"b", "--cont"}, param: 2, token: "--cont"},
+		{params: []string{"sub", "--no-warnings", "x", "--cont"}, param: 4, token: "--cont"},
+		{env: "--verbose", params: []string{"build", "--cont"}, param: 2, token: "--cont"},
+		{env: "--cont", params: []string{"build"}, param: 0, token: "--cont"},
+	}
+	for _, test := range tests {
+		os.Setenv("APP_TEST_OPTS", test.env)
+		_, _, _, err := clippytest.Execute(newApp(), test.params...)
+		var parseErr *clippy.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("%q: got error %v, want a *ParseError", test.params, err)
+			continue
+		}
+		if parseErr.Param != test.param || parseErr.Token != test.token {
+			t.Errorf("%q: got param %d %q, want %d %q", test.params, parseErr.Param, parseErr.Token, test.param, test.token)
+		}
+	}
+	os.Unsetenv("APP_TEST_OPTS")
+}
@@ -0,0 +1,21 @@
+package clippy
+
+import "strings"
+
+// Example is an example invocation of a program or command, for its help.
+type Example struct {
+	Cmd         string // Cmd is the command line. For example, "app remote add origin https://example.com/repo.git".
+	Description string // Description says what the example does. It is optional.
+}
+
+// examplesHelp writes each example as its command line, after its description as a shell comment, so it can be copied as it is.
+func examplesHelp(hc *HelpConfig, examples []Example) string {
+	var sb strings.Builder
+	for _, example := range examples {
+		if example.Description != "" {
+			sb.WriteString(hc.Indent + "# " + example.Description + "\n")
+		}
+		sb.WriteString(hc.Indent + example.Cmd + "\n")
+	}
+	return sb.String()
+}
@@ -1,21 +1,48 @@
 package clippy
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"strconv"
 	"strings"
+	"text/template"
+	"text/template/parse"
 	"unicode"
+	"unicode/utf8"
 )
 
-// EmptyValue is an empty value. This is used for flags where the default value should be the empty string.
+// EmptyValue is an empty value. Flags with an empty DefaultValue already default to the empty string, so it is only kept for compatibility.
 var EmptyValue = "\000"
 
-// Flag is a string value given in the parameters (or by a default value).
+// Flag is a value of some Kind given in the parameters (or by a default value).
 type Flag struct {
-	Name         string // Name of the flag.
-	Alias        rune   // Alias of the flag.
-	Type         string // Type of the flag. For example, "FILENAME" or "URL".
-	Description  string // Description of the flag.
-	DefaultValue string // Default value of the flag. If it is left empty, it is assumed that the flag is mandatory and must be given by the user. Use EmptyValue if the default value should be empty.
+	Name            string                             // Name of the flag.
+	Alias           rune                               // Alias of the flag.
+	Aliases         []rune                             // Additional aliases of the flag. For example, to keep an old alias working after renaming an option.
+	LongAliases     []string                           // Additional long names of the flag. For example, "color" for a flag named "colour".
+	Type            string                             // Type of the flag. For example, "FILENAME" or "URL".
+	Kind            Kind                               // Kind of value the flag holds. Values are checked against it when parsing. It defaults to StringKind.
+	Description     string                             // Description of the flag.
+	EnvVar          string                             // EnvVar is the name of an environment variable, such as "APP_TOKEN", that gives the flag's value if it is not given in the params. It takes precedence over the config file and the default value.
+	DefaultValue    string                             // Default value of the flag. If it is left empty, the flag defaults to the kind's zero value. It may be a template referencing other flags, for example "{{.flags.host}}:8080", or "{{flag "data-dir"}}/cache" for names with hyphens. Templates referencing other templated flags are resolved after them, so they cannot reference each other in a cycle.
+	Choices         []string                           // Choices are the only values the flag can have, such as "json" and "text". They are shown in help. A flag without a default value that is not given is left empty.
+	Required        bool                               // Required flags must be given by the user, in the params, by EnvVar or in the config file.
+	Ask             bool                               // Ask lets the flag have the default value "ask", which prompts for its value when stdin is a terminal, and is an error otherwise. It makes dangerous defaults explicit.
+	RequiredIf      []string                           // Names of flags that make this flag mandatory when any of them is given.
+	RequiredUnless  []string                           // Names of flags that make this flag mandatory when none of them is given.
+	Repeatable      bool                               // Repeatable flags can be given more than once, such as "--include a --include b", collecting every value. See Flags.GetStringSlice.
+	Delimiter       string                             // Delimiter splits each value of a Repeatable flag into several, such as "," for "--tag a,b". A delimiter preceded by a backslash is kept in the value, as in "a\,b", and so is a backslash preceded by one.
+	StdinCapable    bool                               // StdinCapable flags given the value "-" read their value from stdin instead.
+	Completion      string                             // Completion is how the flag's value is completed in shells: "files", "dirs", "hosts", "none", or a command of the program, such as "profiles list", that prints the candidates a line each. If it is empty, the flag's Choices are completed, or files if it has none.
+	Advanced        bool                               // Advanced flags are only shown in help by "--help-all".
+	Hidden          bool                               // Hidden flags can be given but are left out of help, documentation and completion, such as internal or debugging flags.
+	DocsURL         string                             // DocsURL links to further documentation of the flag, in man pages, markdown and the help shown by "--help-all".
+	Deprecated      string                             // Deprecated marks the flag as deprecated with a message, for example saying what to use instead. A warning is given when it is used.
+	RemoveInVersion string                             // RemoveInVersion is the version the deprecated flag will be removed in. Once the program reaches this version, it fails its check.
+	Transform       func(value string) (string, error) // Transform normalizes the flag's value after it is validated, before it is given to the action. For example, lowercasing or resolving a relative path.
+	Validate        func(value string) error           // Validate checks the flag's value, before it is transformed. It is not called for a flag that is not given and has no value. The validate package has ready-made validators.
 }
 
 func (f *Flag) check() error {
@@ -31,16 +58,302 @@ func (f *Flag) check() error {
 		}
 	}
 
-	// Check if the flag's alias is a valid.
-	if f.Alias != rune(0) {
-		if !unicode.IsLetter(f.Alias) && !unicode.IsNumber(f.Alias) {
-			return fmt.Errorf("flag alias is an invalid character: %q", f.Alias)
+	// Check if each of the flag's aliases is valid.
+	for _, alias := range f.Aliases {
+		if alias == rune(0) {
+			return fmt.Errorf("empty alias for flag: %q", f.Name)
+		}
+	}
+	for _, alias := range f.aliases() {
+		if !unicode.IsLetter(alias) && !unicode.IsNumber(alias) {
+			return fmt.Errorf("flag alias is an invalid character: %q", alias)
+		}
+	}
+
+	// Check that each of the flag's long aliases is valid.
+	for _, alias := range f.LongAliases {
+		if alias == "" {
+			return fmt.Errorf("empty long alias for flag: %q", f.Name)
+		}
+		for _, char := range alias {
+			if !unicode.IsLetter(char) && !unicode.IsNumber(char) && char != '-' {
+				return fmt.Errorf("invalid character in long alias of flag %q: %q", f.Name, char)
+			}
+		}
+	}
+
+	// Check that the flag's environment variable name is valid.
+	for _, char := range f.EnvVar {
+		if !unicode.IsLetter(char) && !unicode.IsNumber(char) && char != '_' {
+			return fmt.Errorf("invalid character in environment variable of flag %q: %q", f.Name, char)
+		}
+	}
+
+	// Check that the flag's kind is known.
+	if f.Kind < StringKind || f.Kind > CountKind {
+		return fmt.Errorf("unknown kind of flag %q: %v", f.Name, f.Kind)
+	}
+
+	// Check that required flags have no default value, which would never be used.
+	if f.Required && f.DefaultValue != "" {
+		return fmt.Errorf("required flag %q has a default value", f.Name)
+	}
+
+	// Check that count flags are not repeatable, since giving them again already counts.
+	if f.Repeatable && f.Kind == CountKind {
+		return fmt.Errorf("count flag %q cannot be repeatable", f.Name)
+	}
+
+	// Check that only repeatable flags have a delimiter, since splitting gives several values.
+	if f.Delimiter != "" && !f.Repeatable {
+		return fmt.Errorf("flag %q has a delimiter but is not repeatable", f.Name)
+	}
+
+	// Check that repeatable flags do not read from stdin, which can only be read once.
+	if f.Repeatable && f.StdinCapable {
+		return fmt.Errorf("repeatable flag %q cannot read from stdin", f.Name)
+	}
+
+	// Check that only flags that take a value complete one.
+	if f.Completion != "" && !f.Kind.takesValue() {
+		return fmt.Errorf("%v flag %q cannot complete a value", f.Kind, f.Name)
+	}
+
+	// Check that asking flags ask by default.
+	if f.Ask && f.DefaultValue != askValue {
+		return fmt.Errorf("flag %q asks for its value but its default value is not %q", f.Name, askValue)
+	}
+
+	// Check that the flag's default value is valid for its kind.
+	if f.DefaultValue != EmptyValue && !f.isTemplate() && !f.Ask {
+		if err := f.Kind.check(f.DefaultValue); err != nil {
+			return fmt.Errorf("invalid default value for flag %q: %v", f.Name, err)
+		}
+	}
+
+	// Check that the flag's default value is one of its choices.
+	if f.DefaultValue != "" && f.DefaultValue != EmptyValue && !f.isTemplate() && !f.Ask {
+		if err := f.checkChoice(f.DefaultValue); err != nil {
+			return fmt.Errorf("invalid default value for flag %q: %v", f.Name, err)
+		}
+	}
+
+	// Check that the flag's default value template parses.
+	if f.isTemplate() {
+		if _, err := f.defaultTemplate(nil).Parse(f.DefaultValue); err != nil {
+			return fmt.Errorf("invalid default value template for flag %q: %v", f.Name, err)
 		}
 	}
 
 	return nil
 }
 
+// checkChoice returns an error if the flag has choices and value is not one of them.
+func (f *Flag) checkChoice(value string) error {
+	if len(f.Choices) == 0 {
+		return nil
+	}
+	for _, choice := range f.Choices {
+		if value == choice {
+			return nil
+		}
+	}
+	quoted := make([]string, len(f.Choices))
+	for i, choice := range f.Choices {
+		quoted[i] = strconv.Quote(choice)
+	}
+	return fmt.Errorf("%q is not one of %s", value, strings.Join(quoted, ", "))
+}
+
+func (f *Flag) aliases() []rune {
+	var aliases []rune
+	if f.Alias != rune(0) {
+		aliases = append(aliases, f.Alias)
+	}
+	return append(aliases, f.Aliases...)
+}
+
+// helpDescription returns the description of the flag for help, marked if it is deprecated or required and noting its default value and environment variable.
+func (f *Flag) helpDescription(hc *HelpConfig) string {
+	description := f.Description
+	if f.isDeprecated() {
+		description = "[deprecated] " + description
+	}
+	if f.Required {
+		description = "[required] " + description
+	}
+	if f.Repeatable && f.Delimiter != "" {
+		description = strings.TrimSpace(description + " (repeatable, or separated by " + strconv.Quote(f.Delimiter) + ")")
+	} else if f.Repeatable {
+		description = strings.TrimSpace(description + " (repeatable)")
+	}
+	if len(f.Choices) >= 1 {
+		description = strings.TrimSpace(description + " (one of: " + strings.Join(f.Choices, ", ") + ")")
+	}
+	if f.DefaultValue != "" && f.DefaultValue != EmptyValue {
+		description = strings.TrimSpace(description + " (default: " + hc.formatDefault(f) + ")")
+	}
+	if f.EnvVar != "" {
+		description = strings.TrimSpace(description + " (env: $" + f.EnvVar + ")")
+	}
+	return description
+}
+
+func (f *Flag) isDeprecated() bool {
+	return f.Deprecated != "" || f.RemoveInVersion != ""
+}
+
+func (f *Flag) isTemplate() bool {
+	return strings.Contains(f.DefaultValue, "{{")
+}
+
+// defaultTemplate returns the template for the flag's default value, where "flag" gives the value of the named flag in flags.
+func (f *Flag) defaultTemplate(flags map[string]string) *template.Template {
+	return template.New(f.Name).Option("missingkey=error").Funcs(template.FuncMap{
+		"flag": func(name string) (string, error) {
+			value, ok := flags[name]
+			if !ok {
+				return "", fmt.Errorf("no value for flag %q", name)
+			}
+			return value, nil
+		},
+	})
+}
+
+func (f *Flag) defaultValue(flags map[string]string) (string, error) {
+	if !f.isTemplate() {
+		return f.DefaultValue, nil
+	}
+	tmpl, err := f.defaultTemplate(flags).Parse(f.DefaultValue)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, map[string]interface{}{"flags": flags}); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// defaultRefs returns the names of the flags that the flag's templated default value references, like ".flags.host", "flag "data-dir"" or "index .flags "data-dir"".
+func (f *Flag) defaultRefs() []string {
+	tmpl, err := f.defaultTemplate(nil).Parse(f.DefaultValue)
+	if err != nil || tmpl.Tree == nil {
+		return nil
+	}
+	var refs []string
+	var walk func(node parse.Node)
+	walk = func(node parse.Node) {
+		switch node := node.(type) {
+		case *parse.ListNode:
+			if node != nil {
+				for _, n := range node.Nodes {
+					walk(n)
+				}
+			}
+		case *parse.ActionNode:
+			walk(node.Pipe)
+		case *parse.PipeNode:
+			if node != nil {
+				for _, cmd := range node.Cmds {
+					walk(cmd)
+				}
+			}
+		case *parse.CommandNode:
+			if len(node.Args) >= 2 {
+				if ident, ok := node.Args[0].(*parse.IdentifierNode); ok {
+					if str, ok := node.Args[len(node.Args)-1].(*parse.StringNode); ok && (ident.Ident == "flag" || ident.Ident == "index") {
+						refs = append(refs, str.Text)
+					}
+				}
+			}
+			for _, arg := range node.Args {
+				walk(arg)
+			}
+		case *parse.FieldNode:
+			if len(node.Ident) >= 2 && node.Ident[0] == "flags" {
+				refs = append(refs, node.Ident[1])
+			}
+		case *parse.IfNode:
+			walk(node.Pipe)
+			walk(node.List)
+			walk(node.ElseList)
+		case *parse.RangeNode:
+			walk(node.Pipe)
+			walk(node.List)
+			walk(node.ElseList)
+		case *parse.WithNode:
+			walk(node.Pipe)
+			walk(node.List)
+			walk(node.ElseList)
+		case *parse.TemplateNode:
+			walk(node.Pipe)
+		}
+	}
+	walk(tmpl.Tree.Root)
+	return refs
+}
+
+// orderTemplated returns the flags with templated default values ordered so each comes after the flags its template references, so they can be resolved in turn.
+// It is an error if they reference each other in a cycle, which could never be resolved.
+func orderTemplated(flags []*Flag) ([]*Flag, error) {
+	byName := make(map[string]*Flag)
+	for _, f := range flags {
+		byName[f.Name] = f
+	}
+	var ordered []*Flag
+	done := make(map[string]bool)
+	var visit func(f *Flag, path []string) error
+	visit = func(f *Flag, path []string) error {
+		if done[f.Name] {
+			return nil
+		}
+		for i, name := range path {
+			if name == f.Name {
+				cycle := make([]string, 0, len(path)-i+1)
+				for _, name := range append(path[i:], f.Name) {
+					cycle = append(cycle, strconv.Quote(name))
+				}
+				return fmt.Errorf("default values of flags reference each other in a cycle: %s", strings.Join(cycle, " -> "))
+			}
+		}
+		for _, name := range f.defaultRefs() {
+			if ref := byName[name]; ref != nil {
+				if err := visit(ref, append(path, f.Name)); err != nil {
+					return err
+				}
+			}
+		}
+		done[f.Name] = true
+		ordered = append(ordered, f)
+		return nil
+	}
+	for _, f := range flags {
+		if err := visit(f, nil); err != nil {
+			return nil, err
+		}
+	}
+	return ordered, nil
+}
+
+// templated returns the flags in the set with templated default values.
+func (fs *FlagSet) templated() []*Flag {
+	var templated []*Flag
+	for _, f := range *fs {
+		if f.isTemplate() && !f.Ask {
+			templated = append(templated, f)
+		}
+	}
+	return templated
+}
+
+// FlagGroup is a named FlagSet that can be shared by several commands, such as "connection" flags.
+// Changes to the group apply to every command using it.
+type FlagGroup struct {
+	Name  string  // Name of the group, used to label its flags in help. For example, "connection".
+	Flags FlagSet // Flags in the group.
+}
+
 // FlagSet is a list of Flags.
 type FlagSet []*Flag
 
@@ -59,84 +372,578 @@ func (fs *FlagSet) check() error {
 			return fmt.Errorf("duplicate flag name or alias: %q", f.Name)
 		}
 
-		// Check if the flag's alias already exists.
-		if f.Alias != rune(0) {
-			if _, ok := names[string(f.Alias)]; !ok {
-				names[string(f.Alias)] = struct{}{}
+		// Check if any of the flag's long aliases already exists.
+		for _, alias := range f.LongAliases {
+			if _, ok := names[alias]; !ok {
+				names[alias] = struct{}{}
+			} else {
+				return fmt.Errorf("duplicate flag name or alias: %q", alias)
+			}
+		}
+
+		// Check if any of the flag's aliases already exists.
+		for _, alias := range f.aliases() {
+			if _, ok := names[string(alias)]; !ok {
+				names[string(alias)] = struct{}{}
 			} else {
-				return fmt.Errorf("duplicate flag name or alias: %q", f.Alias)
+				return fmt.Errorf("duplicate flag name or alias: %q", alias)
+			}
+		}
+	}
+
+	// Check that templated default values can be resolved in some order.
+	if _, err := orderTemplated(fs.templated()); err != nil {
+		return err
+	}
+
+	// Check that conditionally required flags refer to flags that exist.
+	for _, f := range *fs {
+		for _, name := range append(append([]string{}, f.RequiredIf...), f.RequiredUnless...) {
+			if fs.lookup(name) == nil {
+				return fmt.Errorf("flag %q is conditionally required on unknown flag %q", f.Name, name)
 			}
 		}
 	}
+
+	return nil
+}
+
+func (fs *FlagSet) lookup(name string) *Flag {
+	for _, flag := range *fs {
+		if flag.Name == name {
+			return flag
+		}
+	}
 	return nil
 }
 
 func (fs *FlagSet) get(name string) *Flag {
 	for _, flag := range *fs {
-		if "--"+flag.Name == name || flag.Alias != rune(0) && "-"+string(flag.Alias) == name {
+		if "--"+flag.Name == name {
 			return flag
 		}
+		for _, alias := range flag.LongAliases {
+			if "--"+alias == name {
+				return flag
+			}
+		}
+		for _, alias := range flag.aliases() {
+			if "-"+string(alias) == name {
+				return flag
+			}
+		}
 	}
 	return nil
 }
 
-func (fs *FlagSet) parse(params []string) (flags map[string]string, args []string, err error) {
-	flags = make(map[string]string)
+// inherit returns fs with the flags of inherited added, leaving out those whose name or aliases are already used, so earlier flags shadow later ones.
+func (fs *FlagSet) inherit(inherited FlagSet) FlagSet {
+	flags := append(FlagSet{}, *fs...)
+	for _, flag := range inherited {
+		shadowed := flags.get("--"+flag.Name) != nil
+		for _, alias := range flag.LongAliases {
+			shadowed = shadowed || flags.get("--"+alias) != nil
+		}
+		for _, alias := range flag.aliases() {
+			shadowed = shadowed || flags.get("-"+string(alias)) != nil
+		}
+		if !shadowed {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+// without returns fs without the flags named in names.
+func (fs *FlagSet) without(names []string) FlagSet {
+	if len(names) == 0 {
+		return *fs
+	}
+	var flags FlagSet
+	for _, flag := range *fs {
+		suppressed := false
+		for _, name := range names {
+			suppressed = suppressed || flag.Name == name
+		}
+		if !suppressed {
+			flags = append(flags, flag)
+		}
+	}
+	return flags
+}
+
+// skip returns how many params at the start of params are flags in fs, including their values.
+func (fs *FlagSet) skip(params []string) int {
+	i := 0
+	for i < len(params) {
+		param := params[i]
+		if j := strings.IndexRune(param, '='); j != -1 && strings.HasPrefix(param, "-") && fs.get(param[:j]) != nil {
+			i++
+		} else if flag := fs.get(param); flag != nil && !flag.Kind.takesValue() {
+			i++
+		} else if flag != nil && i+1 < len(params) {
+			i += 2
+		} else if n := fs.clusterParams(param); n >= 1 && i+n <= len(params) {
+			i += n
+		} else {
+			break
+		}
+	}
+	return i
+}
+
+// clusterParams returns how many params the cluster of aliases param takes, which is 2 if its last flag takes a value and 1 otherwise.
+// It returns 0 if param is not a cluster of flags in fs.
+func (fs *FlagSet) clusterParams(param string) int {
+	if !isCluster(param) {
+		return 0
+	}
+	aliases := []rune(param[1:])
+	for j, alias := range aliases {
+		flag := fs.get("-" + string(alias))
+		if flag == nil || (flag.Kind.takesValue() && j < len(aliases)-1) {
+			return 0
+		} else if flag.Kind.takesValue() {
+			return 2
+		}
+	}
+	return 1
+}
+
+// moveCommand moves the command name following n leading flags in params to the front, so flags can be given before it.
+// The params are the program's from offset on, so their positions are moved with them.
+func (c *Clippy) moveCommand(params []string, offset, n int) []string {
+	if offset+n < len(c.positions) {
+		positions := c.positions[offset : offset+n+1]
+		moved := positions[n]
+		copy(positions[1:], positions[:n])
+		positions[0] = moved
+	}
+	return append(append([]string{params[n]}, params[:n]...), params[n+1:]...)
+}
+
+// isFlagLike returns whether param looks like a flag, such as "--name" or "-a", rather than an argument such as "-" or "-1".
+func isFlagLike(param string) bool {
+	if strings.HasPrefix(param, "--") {
+		return len(param) > 2
+	}
+	return len(param) >= 2 && param[0] == '-' && unicode.IsLetter(rune(param[1]))
+}
+
+// isCluster returns whether param looks like clustered single-letter aliases, such as "-xvf".
+func isCluster(param string) bool {
+	if !strings.HasPrefix(param, "-") || strings.HasPrefix(param, "--") || utf8.RuneCountInString(param) < 3 {
+		return false
+	}
+	for _, char := range param[1:] {
+		if !unicode.IsLetter(char) {
+			return false
+		}
+	}
+	return true
+}
+
+// parse parses the params of the program from offset on for the flags in fs and arguments.
+func (fs *FlagSet) parse(app *Clippy, params []string, offset int) (flags Flags, args []string, err error) {
+	values := make(map[string]string)
+	lists := make(map[string][]string)
 	args = make([]string, 0)
 
+	// Remember where each flag was given, so errors about its value can point at it.
+	given := make(map[string]int)
+	raw := make(map[string][]string)
+	paramErr := func(name string, err error) error {
+		if i, ok := given[name]; ok {
+			return &ParseError{Err: err, Param: app.position(offset + i), Token: params[i]}
+		}
+		return err
+	}
+
+	// set sets the value of flag, given by the param at i.
+	set := func(flag *Flag, i int, value string) {
+		if flag.isDeprecated() {
+			app.warnDeprecated(fmt.Sprintf("flag %q", flag.Name), flag.Deprecated, flag.RemoveInVersion)
+		}
+		given[flag.Name] = i
+		raw[flag.Name] = append(raw[flag.Name], value)
+		values[flag.Name] = value
+		if flag.Repeatable {
+			lists[flag.Name] = append(lists[flag.Name], value)
+		}
+	}
+
+	// count counts another occurrence of the count flag, given by the param at i.
+	count := func(flag *Flag, i int) {
+		n, _ := strconv.Atoi(values[flag.Name])
+		set(flag, i, strconv.Itoa(n+1))
+	}
+
 	// Parse given flag values and arguments.
 	for i := 0; i < len(params); i++ {
 		param := params[i]
-		if flag := fs.get(param); flag != nil {
-			if i+1 < len(params) {
-				flags[flag.Name] = params[i+1]
+
+		// Treat everything after "--" as arguments, even if it looks like a flag.
+		if param == "--" {
+			args = append(args, params[i+1:]...)
+			break
+		}
+
+		// Split off the value of a flag given like "--name=value" or "-a=value". Only the first "=" splits, so values can contain "=".
+		name, value, hasValue := param, "", false
+		if j := strings.IndexRune(param, '='); j != -1 && strings.HasPrefix(param, "-") {
+			if flag := fs.get(param[:j]); flag != nil {
+				name, value, hasValue = param[:j], param[j+1:], true
+			}
+		}
+
+		if flag := fs.get(name); flag != nil {
+			if hasValue {
+				set(flag, i, value)
+			} else if flag.Kind == BoolKind {
+				// Boolean flags are true when given without a value.
+				set(flag, i, "true")
+			} else if flag.Kind == CountKind {
+				count(flag, i)
+			} else if i+1 < len(params) {
+				set(flag, i, params[i+1])
 				i++
 			} else {
-				err = fmt.Errorf("no corresponding value for flag: %q", param)
+				err = &ParseError{Err: errors.New("no corresponding value for flag"), Param: app.position(offset + i), Token: param}
 				return
 			}
+		} else if isCluster(name) {
+			// Expand clustered aliases, such as "-xvf value" for "-x -v -f value". Only the last can take a value.
+			aliases := []rune(name[1:])
+			for j, alias := range aliases {
+				flag := fs.get("-" + string(alias))
+				if flag == nil {
+					err = &ParseError{Err: fmt.Errorf("unknown flag %q in cluster", "-"+string(alias)), Param: app.position(offset + i), Token: param}
+					return
+				} else if flag.Kind == BoolKind {
+					set(flag, i, "true")
+				} else if flag.Kind == CountKind {
+					count(flag, i)
+				} else if j < len(aliases)-1 {
+					err = &ParseError{Err: fmt.Errorf("flag %q needs a value, so it must be last in a cluster", "-"+string(alias)), Param: app.position(offset + i), Token: param}
+					return
+				} else if i+1 < len(params) {
+					set(flag, i, params[i+1])
+					i++
+				} else {
+					err = &ParseError{Err: fmt.Errorf("no corresponding value for flag %q in cluster", "-"+string(alias)), Param: app.position(offset + i), Token: param}
+					return
+				}
+			}
+		} else if isFlagLike(name) {
+			err = &ParseError{Err: errors.New("unknown flag"), Param: app.position(offset + i), Token: param}
+			return
 		} else {
 			args = append(args, param)
 		}
 	}
 
-	// Check for default flag values.
+	// Take values not given in the params from environment variables.
+	fromEnv := make(map[string]bool)
 	for _, f := range *fs {
-		name := f.Name
-		if _, ok := flags[f.Name]; !ok {
-			if f.DefaultValue == "" {
-				err = fmt.Errorf("no given or default value for flag: %q", name)
+		if _, ok := values[f.Name]; ok || f.EnvVar == "" {
+			continue
+		}
+		if value, ok := os.LookupEnv(f.EnvVar); ok {
+			values[f.Name], fromEnv[f.Name] = value, true
+		}
+	}
+
+	// Take values not given otherwise from the config file.
+	for _, f := range *fs {
+		if _, ok := values[f.Name]; ok {
+			continue
+		}
+		if list, ok := app.configValue(f.Name); ok && len(list) >= 1 {
+			if len(list) > 1 && !f.Repeatable {
+				err = fmt.Errorf("config file gives more than one value for flag %q", f.Name)
 				return
-			} else if f.DefaultValue == EmptyValue {
-				flags[name] = ""
+			}
+			values[f.Name] = list[len(list)-1]
+			if f.Repeatable {
+				lists[f.Name] = list
+			}
+		}
+	}
+
+	// Check required and conditionally required flags, reporting every missing flag at once.
+	var missing []string
+	for _, f := range *fs {
+		if _, ok := values[f.Name]; ok {
+			continue
+		}
+		if f.Required {
+			missing = append(missing, fmt.Sprintf("%q", f.Name))
+			continue
+		}
+		for _, name := range f.RequiredIf {
+			if _, ok := values[name]; ok {
+				missing = append(missing, fmt.Sprintf("%q (required if %q is given)", f.Name, name))
+				break
+			}
+		}
+		if len(f.RequiredUnless) >= 1 {
+			given := false
+			for _, name := range f.RequiredUnless {
+				if _, ok := values[name]; ok {
+					given = true
+					break
+				}
+			}
+			if !given {
+				others := make([]string, len(f.RequiredUnless))
+				for i, name := range f.RequiredUnless {
+					others[i] = fmt.Sprintf("%q", name)
+				}
+				missing = append(missing, fmt.Sprintf("%q (required unless %s is given)", f.Name, strings.Join(others, " or ")))
+			}
+		}
+	}
+	if len(missing) >= 1 {
+		err = fmt.Errorf("missing required flags: %s", strings.Join(missing, ", "))
+		return
+	}
+
+	// Check for default flag values. Templated defaults are resolved last so they can reference other flags.
+	var templated []*Flag
+	for _, f := range *fs {
+		name := f.Name
+		if _, ok := values[f.Name]; !ok {
+			if f.DefaultValue == "" && f.Kind == BoolKind {
+				values[name] = "false"
+			} else if f.DefaultValue == "" && f.Kind == CountKind {
+				values[name] = "0"
+			} else if f.DefaultValue == "" || f.DefaultValue == EmptyValue {
+				values[name] = ""
+			} else if f.Ask {
+				if values[name], err = app.ask(f); err != nil {
+					return
+				}
+			} else if f.isTemplate() {
+				templated = append(templated, f)
 			} else {
-				flags[name] = f.DefaultValue
+				values[name] = f.DefaultValue
+			}
+		}
+	}
+
+	// Resolve templated default values after the templated default values they reference.
+	if templated, err = orderTemplated(templated); err != nil {
+		return
+	}
+	for _, f := range templated {
+		var value string
+		if value, err = f.defaultValue(values); err != nil {
+			err = fmt.Errorf("cannot resolve default value for flag %q: %v", f.Name, err)
+			return
+		}
+		values[f.Name] = value
+	}
+
+	// Read values from stdin for flags given "-", which only one of them can be.
+	var stdinFlag *Flag
+	for _, f := range *fs {
+		if !f.StdinCapable || values[f.Name] != "-" {
+			continue
+		}
+		if stdinFlag != nil {
+			err = fmt.Errorf("flags %q and %q cannot both read from stdin", stdinFlag.Name, f.Name)
+			return
+		}
+		stdinFlag = f
+		var b []byte
+		if b, err = ioutil.ReadAll(os.Stdin); err != nil {
+			err = fmt.Errorf("cannot read value for flag %q from stdin: %v", f.Name, err)
+			return
+		}
+		values[f.Name] = string(b)
+	}
+
+	// Collect the single value of repeatable flags that were not given in the params.
+	for _, f := range *fs {
+		if _, ok := lists[f.Name]; f.Repeatable && !ok && values[f.Name] != "" {
+			lists[f.Name] = []string{values[f.Name]}
+		}
+	}
+
+	// Split the values of repeatable flags with a delimiter.
+	for _, f := range *fs {
+		if f.Delimiter == "" || len(lists[f.Name]) == 0 {
+			continue
+		}
+		var list []string
+		for _, value := range lists[f.Name] {
+			list = append(list, splitDelimited(value, f.Delimiter)...)
+		}
+		lists[f.Name], values[f.Name] = list, list[len(list)-1]
+	}
+
+	// each calls fn with each value of f, which is every collected value for a repeatable flag, replacing it with the result.
+	each := func(f *Flag, fn func(value string) (string, error)) error {
+		if !f.Repeatable {
+			value, err := fn(values[f.Name])
+			if err != nil {
+				return paramErr(f.Name, fmt.Errorf("invalid value for flag %q: %v", f.Name, err))
 			}
+			values[f.Name] = value
+			return nil
+		}
+		list := lists[f.Name]
+		for i := range list {
+			value, err := fn(list[i])
+			if err != nil {
+				return paramErr(f.Name, fmt.Errorf("invalid value for flag %q: %v", f.Name, err))
+			}
+			list[i] = value
+		}
+		if len(list) >= 1 {
+			values[f.Name] = list[len(list)-1]
+		}
+		return nil
+	}
+
+	// Read the numbers given by the user in the program's locale, such as "1.234,5" in German, in the form the kinds are checked in.
+	if nf := app.numberFormat(); nf != nil {
+		for _, f := range *fs {
+			if _, ok := given[f.Name]; !ok && !fromEnv[f.Name] || f.Kind != IntKind && f.Kind != FloatKind {
+				continue
+			}
+			if err = each(f, func(value string) (string, error) {
+				if value == "" {
+					return value, nil
+				}
+				number, err := nf.parse(value)
+				if err == nil && f.Kind == IntKind && strings.Contains(number, ".") {
+					err = fmt.Errorf("%q is not a valid %v", value, f.Kind)
+				}
+				return number, err
+			}); err != nil {
+				return
+			}
+		}
+	}
+
+	// Check that each value is valid for its flag's kind.
+	for _, f := range *fs {
+		if err = each(f, func(value string) (string, error) { return value, f.Kind.check(value) }); err != nil {
+			return
+		}
+	}
+
+	// unset returns whether a flag was not given and has no value, which choices and validators leave empty.
+	unset := func(f *Flag) bool {
+		_, ok := given[f.Name]
+		return !ok && values[f.Name] == ""
+	}
+
+	// Check that each value is one of its flag's choices.
+	for _, f := range *fs {
+		if len(f.Choices) == 0 || unset(f) {
+			continue
+		}
+		if err = each(f, func(value string) (string, error) { return value, f.checkChoice(value) }); err != nil {
+			return
 		}
 	}
 
+	// Validate flag values, so an optional flag that is not given does not fail its validator.
+	for _, f := range *fs {
+		if f.Validate == nil || unset(f) {
+			continue
+		}
+		if err = each(f, func(value string) (string, error) { return value, f.Validate(value) }); err != nil {
+			return
+		}
+	}
+
+	// Transform flag values once they are valid, so the action is given normalized values.
+	for _, f := range *fs {
+		if f.Transform == nil {
+			continue
+		}
+		if err = each(f, f.Transform); err != nil {
+			return
+		}
+	}
+
+	flags = Flags{values: values, lists: lists, given: given, raw: raw}
 	return
 }
 
-func (fs *FlagSet) help(indent string) string {
-	var sb strings.Builder
+// splitDelimited splits s on delim, except where delim is preceded by a backslash. A backslash preceded by a backslash is a backslash.
+func splitDelimited(s, delim string) []string {
+	var parts []string
+	var part strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && strings.HasPrefix(s[i+1:], delim):
+			part.WriteString(delim)
+			i += len(delim)
+		case s[i] == '\\' && strings.HasPrefix(s[i+1:], "\\"):
+			part.WriteByte('\\')
+			i++
+		case strings.HasPrefix(s[i:], delim):
+			parts = append(parts, part.String())
+			part.Reset()
+			i += len(delim) - 1
+		default:
+			part.WriteByte(s[i])
+		}
+	}
+	return append(parts, part.String())
+}
 
-	var width int
-	var names []string
+func (fs *FlagSet) docs(indent string) string {
+	var sb strings.Builder
 	for _, flag := range *fs {
-		name := "--" + flag.Name
-		if flag.Alias != rune(0) {
-			name += ", -" + string(flag.Alias)
+		if flag.DocsURL != "" {
+			sb.WriteString(indent + "--" + flag.Name + ": " + flag.DocsURL + "\n")
 		}
-		if l := len(name); l > width {
-			width = l
+	}
+	return sb.String()
+}
+
+// visible returns the flags in the set that are not hidden.
+func (fs FlagSet) visible() FlagSet {
+	visible := make(FlagSet, 0, len(fs))
+	for _, flag := range fs {
+		if !flag.Hidden {
+			visible = append(visible, flag)
 		}
-		names = append(names, name)
 	}
+	return visible
+}
 
-	for i, flag := range *fs {
-		sb.WriteString(fmt.Sprintf("%s%-*s%s%s\n", indent, width, names[i], indent, flag.Description))
+func (fs *FlagSet) hasAdvanced() bool {
+	for _, flag := range *fs {
+		if flag.Advanced && !flag.Hidden {
+			return true
+		}
 	}
+	return false
+}
 
-	return sb.String()
+func (fs *FlagSet) help(hc *HelpConfig, all bool) string {
+	var entries []helpEntry
+	for _, flag := range *fs {
+		if flag.Advanced && !all {
+			continue
+		}
+		entry := helpEntry{name: "--" + flag.Name, description: flag.helpDescription(hc)}
+		for _, alias := range flag.LongAliases {
+			entry.aliases = append(entry.aliases, "--"+alias)
+		}
+		for _, alias := range flag.aliases() {
+			entry.aliases = append(entry.aliases, "-"+string(alias))
+		}
+		entries = append(entries, entry)
+	}
+	if !all && fs.hasAdvanced() {
+		entries = append(entries, helpEntry{name: "...", description: "use \"--help-all\" to show advanced flags"})
+	}
+	return hc.table(entries)
 }
@@ -0,0 +1,194 @@
+package clippy_test
+
+import (
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+	"github.com/patrickmcnamara/clippy/clippytest"
+)
+
+func TestFlagDelimiter(t *testing.T) {
+	var tags []string
+	newApp := func(flag clippy.Flag) *clippy.Clippy {
+		return &clippy.Clippy{
+			Name:    "app",
+			Version: "1.0.0",
+			Flags:   clippy.FlagSet{&flag},
+			Action: func(flags clippy.Flags, args []string) error {
+				tags = flags.GetStringSlice("tag")
+				return nil
+			},
+		}
+	}
+	tag := clippy.Flag{Name: "tag", Repeatable: true, Delimiter: ",", EnvVar: "APP_TEST_TAGS"}
+
+	tests := []struct {
+		flag    clippy.Flag
+		env     string
+		params  []string
+		want    []string
+		wantErr string
+	}{
+		{flag: tag, params: []string{"--tag", "a,b,c"}, want: []string{"a", "b", "c"}},
+		{flag: tag, params: []string{"--tag", "a,b", "--tag=c"}, want: []string{"a", "b", "c"}},
+		{flag: tag, params: []string{"--tag", `a\,b,c`}, want: []string{"a,b", "c"}},
+		{flag: tag, params: []string{"--tag", `a\\,b`}, want: []string{`a\`, "b"}},
+		{flag: tag, params: []string{"--tag", `a\b`}, want: []string{`a\b`}},
+		{flag: tag, params: []string{"--tag", "a,,b"}, want: []string{"a", "", "b"}},
+		{flag: tag, env: "x,y", want: []string{"x", "y"}},
+		{flag: clippy.Flag{Name: "tag", Repeatable: true, Delimiter: "::"}, params: []string{"--tag", "a::b:c"}, want: []string{"a", "b:c"}},
+		{flag: clippy.Flag{Name: "tag", Repeatable: true, Delimiter: ",", DefaultValue: "a,b"}, want: []string{"a", "b"}},
+		{flag: clippy.Flag{Name: "tag", Repeatable: true, Delimiter: ",", Kind: clippy.IntKind}, params: []string{"--tag", "1,x"}, wantErr: `argument 1: "--tag": invalid value for flag "tag": "x" is not a valid int`},
+		{flag: clippy.Flag{Name: "tag", Delimiter: ","}, wantErr: `flag "tag" has a delimiter but is not repeatable`},
+	}
+	for _, test := range tests {
+		os.Setenv("APP_TEST_TAGS", test.env)
+		if test.env == "" {
+			os.Unsetenv("APP_TEST_TAGS")
+		}
+		tags = nil
+		_, _, _, err := clippytest.Execute(newApp(test.flag), test.params...)
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%q: got error %v, want %q", test.params, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.params, err)
+		} else if !reflect.DeepEqual(tags, test.want) {
+			t.Errorf("%q: got %q, want %q", test.params, tags, test.want)
+		}
+	}
+	os.Unsetenv("APP_TEST_TAGS")
+
+	stdout, _, _, _ := clippytest.Execute(newApp(tag), "--help")
+	if want := `(repeatable, or separated by ",")`; !strings.Contains(stdout, want) {
+		t.Errorf("help does not contain %q:\n%s", want, stdout)
+	}
+}
+
+func TestTemplatedDefaults(t *testing.T) {
+	var got map[string]string
+	newApp := func(fs clippy.FlagSet) *clippy.Clippy {
+		return &clippy.Clippy{
+			Name:    "app",
+			Version: "1.0.0",
+			Flags:   fs,
+			Action: func(flags clippy.Flags, args []string) error {
+				got = make(map[string]string)
+				for _, f := range fs {
+					got[f.Name] = flags.GetString(f.Name)
+				}
+				return nil
+			},
+		}
+	}
+
+	tests := []struct {
+		flags   clippy.FlagSet
+		params  []string
+		want    map[string]string
+		wantErr string
+		setup   bool
+	}{
+		{
+			// A flag referencing a templated flag listed after it.
+			flags: clippy.FlagSet{{Name: "url", DefaultValue: "http://{{.flags.addr}}/"}, {Name: "addr", DefaultValue: "{{.flags.host}}:8080"}, {Name: "host", DefaultValue: "localhost"}},
+			want:  map[string]string{"url": "http://localhost:8080/", "addr": "localhost:8080", "host": "localhost"},
+		},
+		{
+			flags:  clippy.FlagSet{{Name: "url", DefaultValue: "http://{{.flags.addr}}/"}, {Name: "addr", DefaultValue: "{{.flags.host}}:8080"}, {Name: "host", DefaultValue: "localhost"}},
+			params: []string{"--host", "example.com"},
+			want:   map[string]string{"url": "http://example.com:8080/", "addr": "example.com:8080", "host": "example.com"},
+		},
+		{
+			flags: clippy.FlagSet{{Name: "cache", DefaultValue: `{{flag "data-dir"}}/cache`}, {Name: "data-dir", DefaultValue: `{{index .flags "home"}}/data`}, {Name: "home", DefaultValue: "/home/app"}},
+			want:  map[string]string{"cache": "/home/app/data/cache", "data-dir": "/home/app/data", "home": "/home/app"},
+		},
+		{
+			flags:   clippy.FlagSet{{Name: "a", DefaultValue: "{{.flags.b}}"}, {Name: "b", DefaultValue: "{{if .flags.c}}{{.flags.a}}{{end}}"}, {Name: "c", DefaultValue: "x"}},
+			wantErr: `default values of flags reference each other in a cycle: "a" -> "b" -> "a"`,
+			setup:   true,
+		},
+		{
+			flags:   clippy.FlagSet{{Name: "a", DefaultValue: `{{flag "a"}}`}},
+			wantErr: `default values of flags reference each other in a cycle: "a" -> "a"`,
+			setup:   true,
+		},
+	}
+	for _, test := range tests {
+		got = nil
+		_, _, code, err := clippytest.Execute(newApp(test.flags), test.params...)
+		if test.wantErr != "" {
+			if err == nil || err.Error() != test.wantErr || test.setup && code != 3 {
+				t.Errorf("%q: got error %v with exit status %d, want %q", test.params, err, code, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.params, err)
+		} else if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %q, want %q", test.params, got, test.want)
+		}
+	}
+
+	// Cycles through inherited flags are found too.
+	app := newApp(clippy.FlagSet{{Name: "a", DefaultValue: "{{.flags.b}}"}})
+	app.Commands = clippy.CommandSet{{Names: []string{"run"}, Flags: clippy.FlagSet{{Name: "b", DefaultValue: "{{.flags.a}}"}}, Action: clippy.DefaultAction}}
+	if err := app.Check(); err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("got error %v, want a cycle through an inherited flag", err)
+	}
+}
+
+func TestTransformOrder(t *testing.T) {
+	var got string
+	newApp := func(f clippy.Flag) *clippy.Clippy {
+		return &clippy.Clippy{
+			Name:    "app",
+			Version: "1.0.0",
+			Flags:   clippy.FlagSet{&f},
+			Action: func(flags clippy.Flags, args []string) error {
+				got = flags.GetString(f.Name)
+				return nil
+			},
+		}
+	}
+	upper := func(value string) (string, error) { return strings.ToUpper(value), nil }
+
+	tests := []struct {
+		flag    clippy.Flag
+		params  []string
+		want    string
+		wantErr string
+	}{
+		// Choices and validators see the value as given, and the action sees it transformed.
+		{flag: clippy.Flag{Name: "format", Choices: []string{"json", "text"}, Transform: upper}, params: []string{"--format", "json"}, want: "JSON"},
+		{flag: clippy.Flag{Name: "format", Choices: []string{"json", "text"}, Transform: upper}, params: []string{"--format", "JSON"}, wantErr: `"JSON" is not one of "json", "text"`},
+		{flag: clippy.Flag{Name: "name", Validate: func(value string) error {
+			if value != strings.ToLower(value) {
+				return errors.New("must be lowercase")
+			}
+			return nil
+		}, Transform: upper}, params: []string{"--name", "x"}, want: "X"},
+	}
+	for _, test := range tests {
+		got = ""
+		_, _, _, err := clippytest.Execute(newApp(test.flag), test.params...)
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%q: got error %v, want %q", test.params, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.params, err)
+		} else if got != test.want {
+			t.Errorf("%q: got %q, want %q", test.params, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package clippy
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Kind is the kind of value a flag holds.
+type Kind int
+
+const (
+	StringKind   Kind = iota // StringKind is any string.
+	BoolKind                 // BoolKind is a boolean, as accepted by strconv.ParseBool. It is true when the flag is given without a value, or can be given like "--flag=false". It defaults to false.
+	IntKind                  // IntKind is an integer, as accepted by strconv.ParseInt with base 0.
+	FloatKind                // FloatKind is a floating-point number, as accepted by strconv.ParseFloat.
+	DurationKind             // DurationKind is a duration, as accepted by time.ParseDuration.
+	CountKind                // CountKind counts how many times the flag is given without a value, so "-vvv" is 3. It can also be given a count like "--verbose=2". It defaults to 0.
+)
+
+func (k Kind) String() string {
+	switch k {
+	case StringKind:
+		return "string"
+	case BoolKind:
+		return "bool"
+	case IntKind:
+		return "int"
+	case FloatKind:
+		return "float"
+	case DurationKind:
+		return "duration"
+	case CountKind:
+		return "count"
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// check checks that value is valid for the kind. An empty value is always valid, and is the kind's zero value.
+func (k Kind) check(value string) error {
+	if value == "" {
+		return nil
+	}
+	var err error
+	switch k {
+	case StringKind:
+	case BoolKind:
+		_, err = strconv.ParseBool(value)
+	case IntKind:
+		_, err = strconv.ParseInt(value, 0, 0)
+	case FloatKind:
+		_, err = strconv.ParseFloat(value, 64)
+	case DurationKind:
+		_, err = time.ParseDuration(value)
+	case CountKind:
+		_, err = strconv.ParseUint(value, 10, 0)
+	default:
+		return fmt.Errorf("unknown flag kind: %v", k)
+	}
+	if err != nil {
+		return fmt.Errorf("%q is not a valid %v", value, k)
+	}
+	return nil
+}
+
+// takesValue returns whether flags of the kind are followed by a value, rather than being given alone like "--verbose".
+func (k Kind) takesValue() bool {
+	return k != BoolKind && k != CountKind
+}
+
+// Flags are the values of flags given to an action, whether given in the params or by default values.
+// Values are checked against their flag's Kind when parsing, so the accessors for that kind do not fail.
+// The accessors return the zero value for flags that do not exist or are of a different kind.
+type Flags struct {
+	values map[string]string
+	lists  map[string][]string
+	given  map[string]int      // given maps the names of the flags given in the params to their positions.
+	raw    map[string][]string // raw maps the names of the flags given in the params to the values they were given, before any were read from stdin or transformed.
+}
+
+// GetString returns the value of the named flag.
+func (f Flags) GetString(name string) string {
+	return f.values[name]
+}
+
+// GetStringSlice returns every value of the named Repeatable flag, in the order they were given.
+// The other accessors return the last value.
+func (f Flags) GetStringSlice(name string) []string {
+	return append([]string(nil), f.lists[name]...)
+}
+
+// GetBool returns the value of the named BoolKind flag.
+func (f Flags) GetBool(name string) bool {
+	b, _ := strconv.ParseBool(f.values[name])
+	return b
+}
+
+// GetInt returns the value of the named IntKind flag.
+func (f Flags) GetInt(name string) int {
+	i, _ := strconv.ParseInt(f.values[name], 0, 0)
+	return int(i)
+}
+
+// GetFloat returns the value of the named FloatKind flag.
+func (f Flags) GetFloat(name string) float64 {
+	x, _ := strconv.ParseFloat(f.values[name], 64)
+	return x
+}
+
+// GetDuration returns the value of the named DurationKind flag.
+func (f Flags) GetDuration(name string) time.Duration {
+	d, _ := time.ParseDuration(f.values[name])
+	return d
+}
+
+// GetCount returns how many times the named CountKind flag was given, such as 3 for "-vvv".
+func (f Flags) GetCount(name string) int {
+	n, _ := strconv.Atoi(f.values[name])
+	return n
+}
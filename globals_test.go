@@ -0,0 +1,84 @@
+package clippy_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+	"github.com/patrickmcnamara/clippy/clippytest"
+)
+
+func TestGlobalFlags(t *testing.T) {
+	var message string
+	var args []string
+	newApp := func(optIn bool) *clippy.Clippy {
+		return &clippy.Clippy{
+			Name:           "app",
+			Version:        "1.0.0",
+			HelpWidthFlag:  optIn,
+			NoWarningsFlag: optIn,
+			PrintCmdFlag:   optIn,
+			Commands: clippy.CommandSet{{
+				Names: []string{"commit"},
+				Flags: clippy.FlagSet{{Name: "message", Alias: 'm', Type: "TEXT"}, {Name: "all", Alias: 'a', Kind: clippy.BoolKind}},
+				Action: func(flags clippy.Flags, a []string) error {
+					message, args = flags.GetString("message"), a
+					return nil
+				},
+			}},
+		}
+	}
+
+	tests := []struct {
+		optIn       bool
+		params      []string
+		wantMessage string
+		wantArgs    []string
+		wantErr     string
+	}{
+		{optIn: true, params: []string{"commit", "--no-warnings", "x"}, wantArgs: []string{"x"}},
+		{optIn: true, params: []string{"commit", "--message", "--no-warnings"}, wantMessage: "--no-warnings", wantArgs: []string{}},
+		{optIn: true, params: []string{"commit", "-am", "--print-command", "x"}, wantMessage: "--print-command", wantArgs: []string{"x"}},
+		{optIn: true, params: []string{"commit", "-m", "--help-width", "x"}, wantMessage: "--help-width", wantArgs: []string{"x"}},
+		{optIn: true, params: []string{"commit", "--", "--no-warnings"}, wantArgs: []string{"--no-warnings"}},
+		{optIn: true, params: []string{"--help-width=80", "commit", "x"}, wantArgs: []string{"x"}},
+		{optIn: true, params: []string{"commit", "--help-width=80"}, wantArgs: []string{}},
+		{optIn: true, params: []string{"commit", "--help-width=abc"}, wantErr: `invalid help width: "abc"`},
+		{optIn: false, params: []string{"commit", "--no-warnings"}, wantErr: `argument 2: "--no-warnings": unknown flag`},
+		{optIn: false, params: []string{"--help-width", "80", "commit"}, wantErr: `argument 1: "--help-width": unknown flag`},
+	}
+	for _, test := range tests {
+		message, args = "", nil
+		_, _, _, err := clippytest.Execute(newApp(test.optIn), test.params...)
+		if test.wantErr != "" {
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("%q: got error %v, want %q", test.params, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.params, err)
+		} else if message != test.wantMessage || !reflect.DeepEqual(args, test.wantArgs) {
+			t.Errorf("%q: got message %q and args %q, want %q and %q", test.params, message, args, test.wantMessage, test.wantArgs)
+		}
+	}
+
+	// Programs that do not opt in can use the names for their own flags.
+	app := &clippy.Clippy{Name: "app", Version: "1.0.0", Flags: clippy.FlagSet{{Name: "no-warnings", Kind: clippy.BoolKind}}, Action: clippy.DefaultAction}
+	if _, _, _, err := clippytest.Execute(app, "--no-warnings"); err != nil {
+		t.Errorf("flag named like a global flag that is not enabled: %v", err)
+	}
+
+	// Programs that opt in cannot use the names for their own flags, since they would never see them.
+	collisions := []*clippy.Clippy{
+		{Name: "app", Version: "1.0.0", HelpWidthFlag: true, Flags: clippy.FlagSet{{Name: "help-width"}}},
+		{Name: "app", Version: "1.0.0", ConfigFile: "config.json", Commands: clippy.CommandSet{{Names: []string{"build"}, Flags: clippy.FlagSet{{Name: "cfg", LongAliases: []string{"config"}}}}}},
+		{Name: "app", Version: "1.0.0", Timeout: 1, Commands: clippy.CommandSet{clippy.Mount(&clippy.Clippy{Name: "sub", Version: "1.0.0", Flags: clippy.FlagSet{{Name: "timeout"}}})}},
+	}
+	for _, app := range collisions {
+		if err := app.Check(); err == nil || !strings.Contains(err.Error(), "uses the name of global flag") {
+			t.Errorf("got error %v, want one for a flag named like a global flag", err)
+		}
+	}
+}
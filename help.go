@@ -0,0 +1,276 @@
+package clippy
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// HelpConfig configures the layout of help output.
+type HelpConfig struct {
+	Indent        string               // Indent is written before each line of a section.
+	Gap           string               // Gap separates the names column from the descriptions column.
+	Width         int                  // Width is the maximum width of a description before it is wrapped. If it is zero, descriptions are not wrapped.
+	LineWidth     int                  // LineWidth is the maximum width of a whole line, such as the terminal width. If it is zero, the CLIPPY_WIDTH or COLUMNS environment variables are used, if set, or else the width of the terminal that help is written to.
+	InlineAliases bool                 // InlineAliases shows aliases next to names. Otherwise, they are shown after the description.
+	FormatDefault func(f *Flag) string // FormatDefault formats the default value of a flag for help and docs. If it is nil, defaults are shown in the canonical form of their kind, such as "1m30s" for "90s".
+	Accessible    bool                 // Accessible writes tables as plain "name: description" lines, without aligned columns or wrapping, for screen readers.
+}
+
+// DefaultHelpConfig is the help layout used when a Clippy has no HelpConfig.
+var DefaultHelpConfig = HelpConfig{
+	Indent:        "\t",
+	Gap:           "\t",
+	InlineAliases: true,
+}
+
+// helpEntry is a row in a names and descriptions table, such as a flag or command.
+type helpEntry struct {
+	name        string
+	aliases     []string
+	description string
+}
+
+func (hc *HelpConfig) table(entries []helpEntry) string {
+	var sb strings.Builder
+
+	var width int
+	names := make([]string, len(entries))
+	descriptions := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.name
+		descriptions[i] = entry.description
+		if len(entry.aliases) >= 1 {
+			if hc.InlineAliases {
+				names[i] += ", " + strings.Join(entry.aliases, ", ")
+			} else {
+				descriptions[i] = strings.TrimSpace(descriptions[i] + " (aliases: " + strings.Join(entry.aliases, ", ") + ")")
+			}
+		}
+		if l := len(names[i]); l > width {
+			width = l
+		}
+	}
+
+	// Write plain lines if asked.
+	if hc.Accessible {
+		for i := range entries {
+			if descriptions[i] == "" {
+				sb.WriteString(hc.Indent + names[i] + "\n")
+			} else {
+				sb.WriteString(hc.Indent + names[i] + ": " + descriptions[i] + "\n")
+			}
+		}
+		return sb.String()
+	}
+
+	// Fit descriptions into the line width.
+	descWidth := hc.Width
+	if hc.LineWidth > 0 {
+		w := hc.LineWidth - textWidth(hc.Indent+strings.Repeat(" ", width)+hc.Gap)
+		if w < minDescWidth {
+			w = minDescWidth
+		}
+		if descWidth == 0 || w < descWidth {
+			descWidth = w
+		}
+	}
+
+	for i := range entries {
+		lines := wrap(descriptions[i], descWidth)
+		sb.WriteString(fmt.Sprintf("%s%-*s%s%s\n", hc.Indent, width, names[i], hc.Gap, lines[0]))
+		for _, line := range lines[1:] {
+			sb.WriteString(fmt.Sprintf("%s%-*s%s%s\n", hc.Indent, width, "", hc.Gap, line))
+		}
+	}
+
+	return sb.String()
+}
+
+// paragraph indents s, wrapping it to fit into the line width.
+func (hc *HelpConfig) paragraph(s string) string {
+	width := 0
+	if hc.LineWidth > 0 {
+		if width = hc.LineWidth - textWidth(hc.Indent); width < minDescWidth {
+			width = minDescWidth
+		}
+	}
+
+	var sb strings.Builder
+	for _, line := range wrap(s, width) {
+		if line == "" {
+			sb.WriteString("\n")
+		} else {
+			sb.WriteString(hc.Indent + line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// formatDefault formats the default value of f, using FormatDefault if it is set.
+func (hc *HelpConfig) formatDefault(f *Flag) string {
+	if hc.FormatDefault != nil {
+		return hc.FormatDefault(f)
+	}
+	if f.isTemplate() || f.Ask {
+		return f.DefaultValue
+	}
+	switch f.Kind {
+	case BoolKind:
+		if b, err := strconv.ParseBool(f.DefaultValue); err == nil {
+			return strconv.FormatBool(b)
+		}
+	case IntKind, CountKind:
+		if i, err := strconv.ParseInt(f.DefaultValue, 0, 0); err == nil {
+			return strconv.FormatInt(i, 10)
+		}
+	case FloatKind:
+		if x, err := strconv.ParseFloat(f.DefaultValue, 64); err == nil {
+			return strconv.FormatFloat(x, 'g', -1, 64)
+		}
+	case DurationKind:
+		if d, err := time.ParseDuration(f.DefaultValue); err == nil {
+			return d.String()
+		}
+	}
+	return f.DefaultValue
+}
+
+// minDescWidth is the narrowest that descriptions are wrapped to, however narrow the line width is.
+const minDescWidth = 20
+
+// envAccessible returns whether the CLIPPY_A11Y environment variable asks for accessible output.
+func envAccessible() bool {
+	accessible, _ := strconv.ParseBool(os.Getenv("CLIPPY_A11Y"))
+	return accessible
+}
+
+// envWidth returns the line width given by the CLIPPY_WIDTH or COLUMNS environment variables, or zero.
+func envWidth() int {
+	for _, key := range []string{"CLIPPY_WIDTH", "COLUMNS"} {
+		if width, err := strconv.Atoi(os.Getenv(key)); err == nil && width > 0 {
+			return width
+		}
+	}
+	return 0
+}
+
+// textWidth returns the width of s when printed, expanding tabs to multiples of eight columns.
+func textWidth(s string) int {
+	var width int
+	for _, char := range s {
+		if char == '\t' {
+			width += 8 - width%8
+		} else {
+			width++
+		}
+	}
+	return width
+}
+
+// wrap splits s into lines of at most width characters, breaking on spaces. Words longer than width are not broken.
+// The lines of s are wrapped separately, so paragraphs and lists stay as written, and keep their indentation.
+func wrap(s string, width int) []string {
+	var lines []string
+	for _, text := range strings.Split(s, "\n") {
+		if width <= 0 {
+			lines = append(lines, text)
+			continue
+		}
+		indent := text[:len(text)-len(strings.TrimLeft(text, " \t"))]
+		words := strings.Fields(text)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+		line := indent + words[0]
+		for _, word := range words[1:] {
+			if len(line)+1+len(word) > width {
+				lines = append(lines, line)
+				line = indent + word
+			} else {
+				line += " " + word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// parseHelpWidth removes the "--help-width" global flag from params, returning its value if it was given.
+func (c *Clippy) parseHelpWidth(params []string) ([]string, int, error) {
+	params, value, err := c.removeValueParam(params, "--help-width")
+	if err != nil || value == "" {
+		return params, 0, err
+	}
+	width, err := strconv.Atoi(value)
+	if err != nil || width <= 0 {
+		return nil, 0, fmt.Errorf("invalid help width: %q", value)
+	}
+	return params, width, nil
+}
+
+// HelpSection is a section of help, such as the flags of a command.
+type HelpSection struct {
+	Title string // Title of the section. For example, "FLAGS" or "FLAG" if there is one.
+	Body  string // Body of the section, with each line indented and ending in a newline.
+}
+
+// HelpData is what help templates are executed with.
+type HelpData struct {
+	Name     string        // Name is how the program or command is invoked. For example, "app remote add".
+	Sections []HelpSection // Sections are the sections of the default help, in order.
+	Program  *Clippy       // Program is the program the help is for.
+	Command  *Command      // Command is the command the help is for, or nil if it is for the program.
+}
+
+// Section returns the body of the section with the given title, or an empty string if there is none.
+// The title matches both its singular and plural forms, so "FLAGS" also finds "FLAG".
+func (d *HelpData) Section(title string) string {
+	for _, section := range d.Sections {
+		if section.Title == title || section.Title+"S" == title || section.Title == title+"S" {
+			return section.Body
+		}
+	}
+	return ""
+}
+
+// helpSections are the sections of help, in order.
+type helpSections []HelpSection
+
+func (hs *helpSections) add(title, body string) {
+	*hs = append(*hs, HelpSection{title, body})
+}
+
+// plural returns title in its plural form if there are n things rather than one, such as "FLAGS".
+func plural(title string, n int) string {
+	if n == 1 {
+		return title
+	}
+	return title + "S"
+}
+
+// renderHelp renders help from data with the help template text, or as the sections in order if text is empty.
+// If the template cannot be executed, the help is rendered without it, with a warning.
+func (c *Clippy) renderHelp(text string, data *HelpData) string {
+	if text != "" {
+		var sb strings.Builder
+		tmpl, err := template.New("help").Parse(text)
+		if err == nil {
+			err = tmpl.Execute(&sb, data)
+		}
+		if err == nil {
+			return strings.TrimRight(sb.String(), "\n")
+		}
+		c.Warn("cannot use help template: %v", err)
+	}
+
+	var sb strings.Builder
+	for _, section := range data.Sections {
+		sb.WriteString(section.Title + ":\n" + section.Body + "\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
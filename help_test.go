@@ -0,0 +1,128 @@
+package clippy_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+	"github.com/patrickmcnamara/clippy/clippytest"
+)
+
+func TestHelpWrap(t *testing.T) {
+	app := &clippy.Clippy{
+		Name:          "app",
+		Version:       "1.0.0",
+		HelpWidthFlag: true,
+		Description:   "First paragraph of the description.\n\nSecond paragraph, which is long enough to be wrapped.\n  - a list item\n  - another list item",
+		Action:        clippy.DefaultAction,
+	}
+
+	tests := []struct {
+		params []string
+		want   []string
+	}{
+		{[]string{"--help"}, []string{
+			"\tFirst paragraph of the description.\n\n\tSecond paragraph, which is long enough to be wrapped.\n\t  - a list item\n\t  - another list item\n",
+		}},
+		{[]string{"--help-width", "40", "--help"}, []string{
+			"\tFirst paragraph of the\n\tdescription.\n\n\tSecond paragraph, which is long\n\tenough to be wrapped.\n\t  - a list item\n\t  - another list item\n",
+		}},
+	}
+	for _, test := range tests {
+		stdout, _, _, err := clippytest.Execute(app, test.params...)
+		if err != nil {
+			t.Errorf("%q: %v", test.params, err)
+			continue
+		}
+		for _, want := range test.want {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("%q: help does not contain %q:\n%s", test.params, want, stdout)
+			}
+		}
+	}
+}
+
+func TestHelpDocumentation(t *testing.T) {
+	app := &clippy.Clippy{
+		Name:    "app",
+		Version: "1.0.0",
+		Flags:   clippy.FlagSet{{Name: "region", DocsURL: "https://example.com/region"}},
+		Commands: clippy.CommandSet{{
+			Names:   []string{"deploy"},
+			DocsURL: "https://example.com/deploy",
+			Flags:   clippy.FlagSet{{Name: "force", Kind: clippy.BoolKind, DocsURL: "https://example.com/force"}},
+			Action:  clippy.DefaultAction,
+		}},
+	}
+
+	tests := []struct {
+		params []string
+		docs   bool
+	}{
+		{[]string{"--help"}, false},
+		{[]string{"--help-all"}, true},
+		{[]string{"deploy", "--help"}, false},
+		{[]string{"deploy", "--help-all"}, true},
+		{[]string{"help", "deploy"}, false},
+		{[]string{"help", "--all", "deploy"}, true},
+	}
+	for _, test := range tests {
+		stdout, _, _, err := clippytest.Execute(app, test.params...)
+		if err != nil {
+			t.Errorf("%q: %v", test.params, err)
+			continue
+		}
+		if docs := strings.Contains(stdout, "DOCUMENTATION:") && strings.Contains(stdout, "https://example.com/"); docs != test.docs {
+			t.Errorf("%q: got documentation %v, want %v:\n%s", test.params, docs, test.docs, stdout)
+		}
+	}
+
+	stdout, _, _, _ := clippytest.Execute(app, "--help")
+	if want := "--help-all"; !strings.Contains(stdout, want) {
+		t.Errorf("help does not mention %q for the documentation links:\n%s", want, stdout)
+	}
+}
+
+func TestHelpCommand(t *testing.T) {
+	var args []string
+	action := func(flags clippy.Flags, a []string) error {
+		args = a
+		return nil
+	}
+
+	// A program without commands gets "help" as an argument.
+	app := &clippy.Clippy{Name: "app", Version: "1.0.0", Action: action}
+	if _, _, _, err := clippytest.Execute(app, "help"); err != nil || len(args) != 1 || args[0] != "help" {
+		t.Errorf("got args %q and error %v, want the argument \"help\"", args, err)
+	}
+
+	app = &clippy.Clippy{Name: "app", Version: "1.0.0", Commands: clippy.CommandSet{
+		{Names: []string{"build"}, Description: "build the project", Action: action},
+		{Names: []string{"remote"}, Commands: clippy.CommandSet{{Names: []string{"add"}, Description: "add a remote", Action: action}}},
+	}}
+	tests := []struct {
+		params  []string
+		want    string
+		wantErr string
+	}{
+		{params: []string{"help"}, want: "COMMANDS:"},
+		{params: []string{"help", "build"}, want: "build the project"},
+		{params: []string{"help", "remote", "add"}, want: "app remote add"},
+		{params: []string{"help", "--search", "remote"}, want: "add a remote"},
+		{params: []string{"help", "nope"}, wantErr: "nope"},
+	}
+	for _, test := range tests {
+		stdout, _, _, err := clippytest.Execute(app, test.params...)
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%q: got error %v, want %q", test.params, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", test.params, err)
+		} else if !strings.Contains(stdout, test.want) {
+			t.Errorf("%q: help does not contain %q:\n%s", test.params, test.want, stdout)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package clippy
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LintIssue is a style issue in a program's definition, found by Lint.
+type LintIssue struct {
+	Path    string // Path is where the issue is, such as "app build" or "app build --force".
+	Message string // Message describes the issue.
+}
+
+func (li LintIssue) String() string {
+	return li.Path + ": " + li.Message
+}
+
+// Lint checks the program's definition for style issues that Check allows, such as missing descriptions.
+// Descriptions are expected to start in lowercase and not end in a period, like "show version and exit".
+// It returns nil if there are no issues, so a test can fail on any issues it reports.
+func (c *Clippy) Lint() []LintIssue {
+	var issues []LintIssue
+	add := func(path, format string, a ...interface{}) {
+		issues = append(issues, LintIssue{Path: path, Message: fmt.Sprintf(format, a...)})
+	}
+
+	// Check descriptions, remembering where each was used to find duplicates.
+	descriptions := make(map[string]string)
+	lintDescription := func(path, description string) {
+		if description == "" {
+			add(path, "missing description")
+			return
+		}
+		if r, _ := utf8.DecodeRuneInString(description); unicode.IsUpper(r) {
+			add(path, "description should start in lowercase: %q", description)
+		}
+		if strings.HasSuffix(description, ".") {
+			add(path, "description should not end in a period: %q", description)
+		}
+		if other, ok := descriptions[description]; ok {
+			add(path, "duplicate description of %q: %q", other, description)
+		} else {
+			descriptions[description] = path
+		}
+	}
+
+	lintFlags := func(path string, fs FlagSet) {
+		for _, f := range fs {
+			flagPath := path + " --" + f.Name
+			lintDescription(flagPath, f.Description)
+			if f.Type == "" && f.Kind.takesValue() {
+				add(flagPath, "missing type placeholder")
+			}
+		}
+	}
+
+	if c.Tagline == "" {
+		add(c.Name, "missing tagline")
+	}
+	lintFlags(c.Name, c.Flags)
+	groups := make(map[*FlagGroup]struct{})
+	c.Commands.walk(c.Name, func(path string, command *Command) {
+		lintDescription(path, command.Description)
+		if command.Usage == "" {
+			add(path, "missing usage")
+		}
+		lintFlags(path, command.Flags)
+		lintFlags(path, command.PersistentFlags)
+
+		// Lint shared flag groups only once.
+		for _, group := range command.FlagGroups {
+			if _, ok := groups[group]; !ok {
+				groups[group] = struct{}{}
+				lintFlags(path+" ["+group.Name+" flags]", group.Flags)
+			}
+		}
+	})
+
+	return issues
+}
@@ -0,0 +1,37 @@
+package clippy_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+)
+
+func TestLint(t *testing.T) {
+	app := &clippy.Clippy{
+		Name:    "app",
+		Version: "1.0.0",
+		Tagline: "the program",
+		Flags:   clippy.FlagSet{{Name: "verbose", Kind: clippy.BoolKind, Description: "log more"}},
+		Commands: clippy.CommandSet{{
+			Names:           []string{"remote"},
+			Description:     "manage remotes",
+			Usage:           "app remote COMMAND",
+			Flags:           clippy.FlagSet{{Name: "all", Kind: clippy.BoolKind, Description: "Show all."}},
+			PersistentFlags: clippy.FlagSet{{Name: "remote"}},
+		}},
+	}
+	want := []string{
+		`app remote --all: description should start in lowercase: "Show all."`,
+		`app remote --all: description should not end in a period: "Show all."`,
+		"app remote --remote: missing description",
+		"app remote --remote: missing type placeholder",
+	}
+	var got []string
+	for _, issue := range app.Lint() {
+		got = append(got, issue.String())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got issues %q, want %q", got, want)
+	}
+}
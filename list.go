@@ -0,0 +1,76 @@
+package clippy
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// commandListing describes a command for the "--list-commands" global flag.
+type commandListing struct {
+	Path        string   `json:"path"`
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases"`
+	Description string   `json:"description"`
+}
+
+func (c *Clippy) listings() []commandListing {
+	listings := make([]commandListing, 0, len(c.Commands))
+	c.Commands.walkVisible(c.Name, func(path string, command *Command) {
+		listings = append(listings, commandListing{
+			Path:        path,
+			Name:        command.Names[0],
+			Aliases:     command.aliases(),
+			Description: command.Description,
+		})
+	})
+	return listings
+}
+
+// listCommands runs the "--list-commands" global flag with the given params.
+func (c *Clippy) listCommands(params []string) (string, error) {
+	listings := c.listings()
+
+	// Print as JSON if asked for.
+	if len(params) >= 1 && params[0] == "--json" {
+		b, err := json.MarshalIndent(listings, "", "  ")
+		return string(b), err
+	}
+
+	entries := make([]helpEntry, len(listings))
+	for i, listing := range listings {
+		entries[i] = helpEntry{name: listing.Path, aliases: listing.Aliases, description: listing.Description}
+	}
+	hc := *c.helpConfig()
+	hc.Indent = ""
+	return strings.TrimRight(hc.table(entries), "\n"), nil
+}
+
+// tree renders the command hierarchy as an ASCII tree.
+func (c *Clippy) tree() string {
+	var sb strings.Builder
+	sb.WriteString(c.Name)
+	if c.Tagline != "" {
+		sb.WriteString(" - " + c.Tagline)
+	}
+	sb.WriteRune('\n')
+	writeTree(&sb, "", c.Commands.visible(), c.Accessible())
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// writeTree writes the commands in cs as branches of a tree. If accessible is true, they are only indented, without ASCII art.
+func writeTree(sb *strings.Builder, prefix string, cs CommandSet, accessible bool) {
+	for i, command := range cs {
+		branch, indent := "|-- ", "|   "
+		if accessible {
+			branch, indent = "  ", "  "
+		} else if i == len(cs)-1 {
+			branch, indent = "`-- ", "    "
+		}
+		sb.WriteString(prefix + branch + command.Names[0])
+		if command.Description != "" {
+			sb.WriteString(" - " + command.Description)
+		}
+		sb.WriteRune('\n')
+		writeTree(sb, prefix+indent, command.Commands.visible(), accessible)
+	}
+}
@@ -0,0 +1,112 @@
+package clippy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LocaleEnv is the value of Clippy's Locale that takes the locale from the LC_ALL, LC_NUMERIC or LANG environment variables, as the C library does.
+const LocaleEnv = "env"
+
+// numberFormat is how numbers are written in a locale: the decimal separator, and the separators that can group the digits of the integer part in threes.
+type numberFormat struct {
+	locale  string
+	decimal rune
+	groups  string
+}
+
+// numberFormats maps languages to how they write numbers, such as "1.234,5" in German. Languages that write them like English, "1,234.5", are left out.
+var numberFormats = map[string]numberFormat{}
+
+func init() {
+	for _, lang := range []string{"da", "de", "el", "es", "id", "it", "nl", "pt", "ro", "sl", "tr"} {
+		numberFormats[lang] = numberFormat{locale: lang, decimal: ',', groups: "."}
+	}
+	for _, lang := range []string{"bg", "cs", "fi", "fr", "hu", "nb", "nn", "no", "pl", "ru", "sk", "sv", "uk"} {
+		numberFormats[lang] = numberFormat{locale: lang, decimal: ',', groups: "   "}
+	}
+}
+
+// localeLanguage returns the language of locale, such as "de" for "de_DE.UTF-8", or "" for the C locale.
+func localeLanguage(locale string) string {
+	if i := strings.IndexAny(locale, "_-.@"); i != -1 {
+		locale = locale[:i]
+	}
+	if locale == "C" || locale == "POSIX" {
+		return ""
+	}
+	return strings.ToLower(locale)
+}
+
+// checkLocale checks that an explicit Locale is one whose language is known, since numbers in it would otherwise be read wrongly.
+func checkLocale(locale string) error {
+	if locale == "" || locale == LocaleEnv {
+		return nil
+	}
+	lang := localeLanguage(locale)
+	if _, ok := numberFormats[lang]; !ok && lang != "" && lang != "en" {
+		return fmt.Errorf("unknown locale: %q", locale)
+	}
+	return nil
+}
+
+// numberFormat returns how the program's locale writes numbers, or nil if it writes them like Go does, as in English or the C locale.
+func (c *Clippy) numberFormat() *numberFormat {
+	locale := c.Locale
+	if locale == LocaleEnv {
+		locale = ""
+		for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+			if locale = os.Getenv(name); locale != "" {
+				break
+			}
+		}
+	}
+	if nf, ok := numberFormats[localeLanguage(locale)]; ok {
+		return &nf
+	}
+	return nil
+}
+
+// parse returns the number value written in the format in the canonical form, such as "1234.5" for "1.234,5" in German.
+// Group separators must group the digits in threes, so a mistyped decimal separator, such as "1.5" in German, is an error rather than fifteen.
+func (nf *numberFormat) parse(value string) (string, error) {
+	invalid := fmt.Errorf("%q is not a number in locale %q", value, nf.locale)
+	sign := ""
+	if strings.HasPrefix(value, "-") || strings.HasPrefix(value, "+") {
+		sign, value = value[:1], value[1:]
+	}
+	integer, fraction := value, ""
+	if i := strings.IndexRune(value, nf.decimal); i != -1 {
+		integer, fraction = value[:i], "."+value[i+utf8.RuneLen(nf.decimal):]
+	}
+
+	// Leave out the group separators, checking that each group has three digits.
+	var groups []string
+	start := 0
+	for i, r := range integer {
+		if strings.ContainsRune(nf.groups, r) {
+			groups = append(groups, integer[start:i])
+			start = i + utf8.RuneLen(r)
+		}
+	}
+	groups = append(groups, integer[start:])
+	for i, group := range groups[1:] {
+		if len(group) != 3 || !isDigits(group) || i == 0 && (len(groups[0]) == 0 || len(groups[0]) > 3 || !isDigits(groups[0])) {
+			return "", invalid
+		}
+	}
+	return sign + strings.Join(groups, "") + fraction, nil
+}
+
+// isDigits returns whether s is made only of the digits 0 to 9.
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r > unicode.MaxASCII || !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return true
+}
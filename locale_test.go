@@ -0,0 +1,83 @@
+package clippy_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+	"github.com/patrickmcnamara/clippy/clippytest"
+)
+
+func TestLocale(t *testing.T) {
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if value, ok := os.LookupEnv(name); ok {
+			defer os.Setenv(name, value)
+		} else {
+			defer os.Unsetenv(name)
+		}
+		os.Unsetenv(name)
+	}
+
+	var ratio float64
+	var count int
+	newApp := func(locale string) *clippy.Clippy {
+		return &clippy.Clippy{
+			Name:    "app",
+			Version: "1.0.0",
+			Locale:  locale,
+			Flags: clippy.FlagSet{
+				{Name: "ratio", Kind: clippy.FloatKind, DefaultValue: "0.5", EnvVar: "APP_TEST_RATIO"},
+				{Name: "count", Kind: clippy.IntKind},
+			},
+			Action: func(flags clippy.Flags, args []string) error {
+				ratio, count = flags.GetFloat("ratio"), flags.GetInt("count")
+				return nil
+			},
+		}
+	}
+
+	tests := []struct {
+		locale    string
+		lang      string
+		env       string
+		params    []string
+		wantRatio float64
+		wantCount int
+		wantErr   string
+	}{
+		{locale: "", params: []string{"--ratio", "1234.5", "--count", "1000"}, wantRatio: 1234.5, wantCount: 1000},
+		{locale: "", params: []string{"--ratio", "1,5"}, wantErr: `argument 1: "--ratio": invalid value for flag "ratio": "1,5" is not a valid float`},
+		{locale: "de", params: []string{"--ratio", "1.234,5", "--count", "1.000"}, wantRatio: 1234.5, wantCount: 1000},
+		{locale: "de_DE.UTF-8", params: []string{"--ratio", "-0,25"}, wantRatio: -0.25},
+		{locale: "de", wantRatio: 0.5},
+		{locale: "de", env: "2,5", wantRatio: 2.5},
+		{locale: "de", params: []string{"--ratio", "1.5"}, wantErr: `argument 1: "--ratio": invalid value for flag "ratio": "1.5" is not a number in locale "de"`},
+		{locale: "de", params: []string{"--count", "1,5"}, wantErr: `argument 1: "--count": invalid value for flag "count": "1,5" is not a valid int`},
+		{locale: "fr", params: []string{"--ratio", "1 234,5", "--count", "1 000"}, wantRatio: 1234.5, wantCount: 1000},
+		{locale: "en_US", params: []string{"--ratio", "1234.5"}, wantRatio: 1234.5},
+		{locale: clippy.LocaleEnv, lang: "de_DE.UTF-8", params: []string{"--ratio", "1,5"}, wantRatio: 1.5},
+		{locale: clippy.LocaleEnv, lang: "C", params: []string{"--ratio", "1.5"}, wantRatio: 1.5},
+		{locale: "xx", wantErr: `unknown locale: "xx"`},
+	}
+	for _, test := range tests {
+		os.Setenv("LANG", test.lang)
+		os.Setenv("APP_TEST_RATIO", test.env)
+		if test.env == "" {
+			os.Unsetenv("APP_TEST_RATIO")
+		}
+		ratio, count = 0, 0
+		_, _, _, err := clippytest.Execute(newApp(test.locale), test.params...)
+		if test.wantErr != "" {
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf("%s %q: got error %v, want %q", test.locale, test.params, err, test.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s %q: %v", test.locale, test.params, err)
+		} else if ratio != test.wantRatio || count != test.wantCount {
+			t.Errorf("%s %q: got %v and %d, want %v and %d", test.locale, test.params, ratio, count, test.wantRatio, test.wantCount)
+		}
+	}
+	os.Unsetenv("APP_TEST_RATIO")
+}
@@ -0,0 +1,63 @@
+package clippy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// lockPollInterval is how often a queued instance checks whether the lock was released.
+const lockPollInterval = 100 * time.Millisecond
+
+// lock takes the lock file called name in the program's state directory, returning a function that releases it.
+// If another running instance holds the lock, it waits for up to wait for it to be released, then returns an error saying so.
+func (c *Clippy) lock(name string, wait time.Duration) (unlock func(), err error) {
+	// Allow the lock to be skipped.
+	if c.noLock {
+		return func() {}, nil
+	}
+
+	dir, err := c.StateDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, name+".lock")
+
+	deadline := time.Now().Add(wait)
+	for {
+		unlock, pid, err := tryLock(path)
+		if err != nil || unlock != nil {
+			return unlock, err
+		}
+
+		// Queue until the lock is released, the wait is over or the program is cancelled.
+		if time.Now().After(deadline) {
+			running := "running"
+			if pid != 0 {
+				running = fmt.Sprintf("running (pid %d)", pid)
+			}
+			if wait > 0 {
+				return nil, fmt.Errorf("%s is still %s after waiting %v", name, running, wait)
+			}
+			return nil, fmt.Errorf("%s is already %s", name, running)
+		}
+		select {
+		case <-time.After(lockPollInterval):
+		case <-c.ctx.Done():
+			return nil, c.ctx.Err()
+		}
+	}
+}
+
+// readLockPid returns the pid written to the lock file at path, or 0 if it cannot be read, such as while the instance holding it is still writing it.
+func readLockPid(path string) int {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, _ := strconv.Atoi(strings.TrimSpace(string(b)))
+	return pid
+}
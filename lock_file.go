@@ -0,0 +1,37 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd || dragonfly || windows
+// +build linux darwin freebsd netbsd openbsd dragonfly windows
+
+package clippy
+
+import (
+	"fmt"
+	"os"
+)
+
+// tryLock takes the lock file at path with a file lock, returning a function that releases it.
+// If another instance holds the lock, unlock is nil and pid is that instance, or 0 if it has not written its pid yet.
+// The operating system releases the lock when the instance holding it exits, so a lock file left behind is never stale.
+func tryLock(path string) (unlock func(), pid int, err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, 0, err
+	}
+	locked, err := lockFile(f)
+	if err != nil || !locked {
+		f.Close()
+		if err != nil {
+			return nil, 0, fmt.Errorf("cannot take lock %q: %v", path, err)
+		}
+		return nil, readLockPid(path), nil
+	}
+
+	// Write the pid for the instances that find the lock taken. The file is kept, since removing it would let another instance lock a file that is no longer at path.
+	if err := f.Truncate(0); err == nil {
+		_, err = f.WriteAt([]byte(fmt.Sprint(os.Getpid())), 0)
+	}
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return func() { f.Close() }, 0, nil
+}
@@ -0,0 +1,57 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly && !windows
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly,!windows
+
+package clippy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// tryLock takes the lock file at path, returning a function that releases it.
+// If another running instance holds the lock, unlock is nil and pid is that instance.
+// There is no file lock here, so the lock file is written in full before it is linked into place, and a stale one is replaced atomically and checked again.
+func tryLock(path string) (unlock func(), pid int, err error) {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return nil, 0, err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.WriteString(strconv.Itoa(os.Getpid()))
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Link the lock file into place, which fails if there already is one.
+	if err := os.Link(tmp.Name(), path); err == nil {
+		return releaseLock(path), 0, nil
+	} else if !os.IsExist(err) {
+		return nil, 0, err
+	}
+
+	// Replace the lock file if the instance holding it is no longer running, then check that no other instance replaced it too.
+	if pid := readLockPid(path); pid != 0 && processExists(pid) {
+		return nil, pid, nil
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, 0, err
+	}
+	if pid := readLockPid(path); pid != os.Getpid() {
+		return nil, pid, nil
+	}
+	return releaseLock(path), 0, nil
+}
+
+// releaseLock returns a function that removes the lock file at path if it is still this instance's.
+func releaseLock(path string) func() {
+	return func() {
+		if readLockPid(path) == os.Getpid() {
+			os.Remove(path)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package clippy_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+	"github.com/patrickmcnamara/clippy/clippytest"
+)
+
+func TestSingleInstance(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clippy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.Setenv("XDG_STATE_HOME", dir)
+	defer os.Unsetenv("XDG_STATE_HOME")
+	lockPath := filepath.Join(dir, "app", "app.lock")
+
+	tests := []struct {
+		name    string
+		held    bool   // held is whether another instance is running while the program runs.
+		file    string // file is left in place of the lock file before the program runs, if it is not empty.
+		params  []string
+		wantErr string
+	}{
+		{name: "free"},
+		{name: "held", held: true, wantErr: "app is already running (pid"},
+		{name: "no lock", held: true, params: []string{"--no-lock"}},
+		{name: "dead pid", file: "999999999"},
+		{name: "empty file", file: " "},
+	}
+	for _, test := range tests {
+		os.RemoveAll(filepath.Dir(lockPath))
+		if test.file != "" {
+			os.MkdirAll(filepath.Dir(lockPath), 0700)
+			if err := ioutil.WriteFile(lockPath, []byte(test.file), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		var inner, outer error
+		program := func(action clippy.Action) *clippy.Clippy {
+			return &clippy.Clippy{Name: "app", Version: "1.0.0", SingleInstance: true, Action: action}
+		}
+		if test.held {
+			_, _, _, outer = clippytest.Execute(program(func(flags clippy.Flags, args []string) error {
+				_, _, _, inner = clippytest.Execute(program(clippy.DefaultAction), test.params...)
+				return nil
+			}))
+		} else {
+			_, _, _, inner = clippytest.Execute(program(clippy.DefaultAction), test.params...)
+		}
+		if outer != nil {
+			t.Errorf("%s: holding instance failed: %v", test.name, outer)
+		}
+		if test.wantErr == "" && inner != nil {
+			t.Errorf("%s: got error %v, want none", test.name, inner)
+		} else if test.wantErr != "" && (inner == nil || !strings.Contains(inner.Error(), test.wantErr)) {
+			t.Errorf("%s: got error %v, want %q", test.name, inner, test.wantErr)
+		}
+
+		// The lock is released once the program exits.
+		if _, _, _, err := clippytest.Execute(program(clippy.DefaultAction)); err != nil {
+			t.Errorf("%s: lock was not released: %v", test.name, err)
+		}
+	}
+}
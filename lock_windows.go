@@ -0,0 +1,28 @@
+package clippy
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var procLockFileEx = syscall.NewLazyDLL("kernel32.dll").NewProc("LockFileEx")
+
+const (
+	lockfileFailImmediately = 0x1 // LOCKFILE_FAIL_IMMEDIATELY
+	lockfileExclusiveLock   = 0x2 // LOCKFILE_EXCLUSIVE_LOCK
+	errorLockViolation      = 33  // ERROR_LOCK_VIOLATION
+)
+
+// lockFile takes an exclusive lock on f without waiting, returning whether it did. The lock is released when f is closed.
+// It locks a byte far past the end of the file, so other instances can still read the pid in it.
+func lockFile(f *os.File) (bool, error) {
+	ol := syscall.Overlapped{OffsetHigh: 1}
+	r, _, err := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if r != 0 {
+		return true, nil
+	} else if errno, ok := err.(syscall.Errno); ok && errno == errorLockViolation {
+		return false, nil
+	}
+	return false, err
+}
@@ -0,0 +1,180 @@
+package clippy
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Man returns a man page for the program in roff format, covering its flags, commands, exit codes and authors.
+// It is for packagers to ship with the program, such as "app.1".
+func (c *Clippy) Man() ([]byte, error) {
+	if err := c.Check(); err != nil {
+		return nil, err
+	}
+
+	hc := c.helpConfig()
+	var sb strings.Builder
+	sb.WriteString(".TH " + roffQuote(strings.ToUpper(c.Name)) + " 1 \"\" " + roffQuote(c.version()) + " \"User Commands\"\n")
+
+	// NAME
+	sb.WriteString(".SH NAME\n")
+	sb.WriteString(roffEscape(c.Name))
+	if c.Tagline != "" {
+		sb.WriteString(" \\- " + roffEscape(c.Tagline))
+	}
+	sb.WriteRune('\n')
+
+	// SYNOPSIS
+	sb.WriteString(".SH SYNOPSIS\n")
+	sb.WriteString(".B " + roffEscape(c.Name) + "\n")
+	sb.WriteString(roffEscape(c.usage()) + "\n")
+
+	// DESCRIPTION
+	if c.Description != "" {
+		sb.WriteString(".SH DESCRIPTION\n")
+		sb.WriteString(roffEscape(c.Description) + "\n")
+	}
+
+	// OPTIONS
+	if flags := c.Flags.visible(); len(flags) >= 1 {
+		sb.WriteString(".SH OPTIONS\n")
+		sb.WriteString(manFlags(hc, flags))
+	}
+
+	// COMMANDS
+	if len(c.Commands.visible()) >= 1 {
+		sb.WriteString(".SH COMMANDS\n")
+		c.Commands.walkMounted(c.Name, func(path string, command *Command) {
+			sb.WriteString(".TP\n")
+			sb.WriteString(".B " + roffEscape(path) + "\n")
+			description := command.Description
+			if command.isDeprecated() {
+				description = "[deprecated] " + description
+			}
+			if aliases := command.aliases(); len(aliases) >= 1 {
+				description = strings.TrimSpace(description + " (aliases: " + strings.Join(aliases, ", ") + ")")
+			}
+			if description != "" {
+				sb.WriteString(roffEscape(description) + "\n")
+			}
+			if command.DocsURL != "" {
+				sb.WriteString(".br\n")
+				sb.WriteString(roffEscape("See also "+command.DocsURL+".") + "\n")
+			}
+			fs, exitCodes := command.flags(), command.ExitCodes
+			if command.mounted != nil {
+				fs, exitCodes = command.mounted.Flags, command.mounted.ExitCodes
+			}
+			if fs := fs.visible(); len(fs) >= 1 {
+				sb.WriteString(".RS\n")
+				sb.WriteString(manFlags(hc, fs))
+				sb.WriteString(".RE\n")
+			}
+			if len(exitCodes) >= 1 {
+				sb.WriteString(".RS\n.PP\nExit status:\n")
+				sb.WriteString(manExitCodes(exitCodes))
+				sb.WriteString(".RE\n")
+			}
+		})
+	}
+
+	// EXAMPLES
+	examples := append([]Example{}, c.Examples...)
+	c.Commands.walkMounted(c.Name, func(path string, command *Command) {
+		examples = append(examples, command.Examples...)
+		if command.mounted != nil {
+			examples = append(examples, command.mounted.Examples...)
+		}
+	})
+	if len(examples) >= 1 {
+		sb.WriteString(".SH EXAMPLES\n")
+		for _, example := range examples {
+			sb.WriteString(".PP\n")
+			if example.Description != "" {
+				sb.WriteString(roffEscape(example.Description) + "\n")
+				sb.WriteString(".PP\n")
+			}
+			sb.WriteString(".RS\n.nf\n" + roffEscape(example.Cmd) + "\n.fi\n.RE\n")
+		}
+	}
+
+	// EXIT STATUS
+	if len(c.ExitCodes) >= 1 {
+		sb.WriteString(".SH \"EXIT STATUS\"\n")
+		sb.WriteString(manExitCodes(c.ExitCodes))
+	}
+
+	// AUTHORS
+	if len(c.Authors) >= 1 {
+		sb.WriteString(".SH AUTHOR")
+		if len(c.Authors) > 1 {
+			sb.WriteString("S")
+		}
+		sb.WriteRune('\n')
+		for i, author := range c.Authors {
+			if i >= 1 {
+				sb.WriteString(".br\n")
+			}
+			sb.WriteString(roffEscape(author.String()) + "\n")
+		}
+	}
+
+	return []byte(sb.String()), nil
+}
+
+// manFlags renders flags as a roff tagged paragraph list.
+func manFlags(hc *HelpConfig, fs FlagSet) string {
+	var sb strings.Builder
+	for _, flag := range fs {
+		names := []string{"\\-\\-" + roffEscape(flag.Name)}
+		for _, alias := range flag.LongAliases {
+			names = append(names, "\\-\\-"+roffEscape(alias))
+		}
+		for _, alias := range flag.aliases() {
+			names = append(names, "\\-"+roffEscape(string(alias)))
+		}
+		sb.WriteString(".TP\n")
+		sb.WriteString(".B " + strings.Join(names, ", "))
+		if flag.Type != "" {
+			sb.WriteString(" \\fI" + roffEscape(flag.Type) + "\\fR")
+		}
+		sb.WriteRune('\n')
+		if description := flag.helpDescription(hc); description != "" {
+			sb.WriteString(roffEscape(description) + "\n")
+		}
+		if flag.DocsURL != "" {
+			sb.WriteString(".br\n")
+			sb.WriteString(roffEscape("See also "+flag.DocsURL+".") + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// manExitCodes renders exit codes as a roff tagged paragraph list.
+func manExitCodes(exitCodes []ExitCode) string {
+	var sb strings.Builder
+	for _, exitCode := range exitCodes {
+		sb.WriteString(".TP\n")
+		sb.WriteString(".B " + strconv.Itoa(exitCode.Code) + "\n")
+		sb.WriteString(roffEscape(exitCode.Description) + "\n")
+	}
+	return sb.String()
+}
+
+// roffEscape escapes s for use as roff text, so that backslashes, hyphens and leading control characters are printed as they are.
+func roffEscape(s string) string {
+	s = strings.Replace(s, "\\", "\\e", -1)
+	s = strings.Replace(s, "-", "\\-", -1)
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			lines[i] = "\\&" + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// roffQuote escapes and quotes s as a roff macro argument.
+func roffQuote(s string) string {
+	return "\"" + strings.Replace(roffEscape(s), "\"", "\\(dq", -1) + "\""
+}
@@ -0,0 +1,194 @@
+package clippy
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Markdown returns reference documentation for the program in markdown, with one page for the program and one for each command.
+// The pages are keyed by file name, such as "app.md" and "app-remote-add.md", and link to each other by those names.
+func (c *Clippy) Markdown() (map[string][]byte, error) {
+	if err := c.Check(); err != nil {
+		return nil, err
+	}
+
+	pages := make(map[string][]byte)
+	hc := c.helpConfig()
+
+	// Program page.
+	var sb strings.Builder
+	sb.WriteString("# " + c.Name + "\n\n")
+	if c.Tagline != "" {
+		sb.WriteString(c.Tagline + "\n\n")
+	}
+	if c.Description != "" {
+		sb.WriteString(c.Description + "\n\n")
+	}
+	sb.WriteString("Version " + c.Version + ".\n\n")
+	sb.WriteString("## Usage\n\n```\n" + c.Name + " " + c.usage() + "\n```\n\n")
+	sb.WriteString(markdownCommands(c.Name, c.Commands))
+	sb.WriteString(markdownFlags(hc, "Flags", c.Flags))
+	sb.WriteString(markdownExamples(c.Examples))
+	sb.WriteString(markdownExitCodes(c.ExitCodes))
+	if len(c.Authors) >= 1 {
+		sb.WriteString("## Author")
+		if len(c.Authors) > 1 {
+			sb.WriteString("s")
+		}
+		sb.WriteString("\n\n")
+		for _, author := range c.Authors {
+			sb.WriteString("- " + markdownEscape(author.String()) + "\n")
+		}
+		sb.WriteRune('\n')
+	}
+	pages[markdownFile(c.Name)] = []byte(strings.TrimRight(sb.String(), "\n") + "\n")
+
+	// Command pages, with the pages of mounted programs.
+	var err error
+	c.Commands.walkVisible(c.Name, func(path string, command *Command) {
+		if command.mounted != nil {
+			sub := *command.mounted
+			sub.Name = path
+			subPages, serr := sub.Markdown()
+			if err == nil {
+				err = serr
+			}
+			for name, page := range subPages {
+				pages[name] = page
+			}
+			parent := path[:strings.LastIndex(path, " ")]
+			if page, ok := pages[markdownFile(path)]; ok {
+				pages[markdownFile(path)] = append(page, []byte("\nBack to ["+parent+"]("+markdownFile(parent)+").\n")...)
+			}
+			return
+		}
+
+		var sb strings.Builder
+		sb.WriteString("# " + path + "\n\n")
+		if command.isDeprecated() {
+			sb.WriteString("**Deprecated.** " + command.Deprecated + "\n\n")
+		}
+		if command.Description != "" {
+			sb.WriteString(command.Description + "\n\n")
+		}
+		if aliases := command.aliases(); len(aliases) >= 1 {
+			sb.WriteString("Aliases: `" + strings.Join(aliases, "`, `") + "`.\n\n")
+		}
+		sb.WriteString("## Usage\n\n```\n" + path + " " + command.usage() + "\n```\n\n")
+		sb.WriteString(markdownCommands(path, command.Commands))
+		sb.WriteString(markdownFlags(hc, "Flags", command.Flags))
+		for _, group := range command.FlagGroups {
+			sb.WriteString(markdownFlags(hc, group.Name+" flags", group.Flags))
+		}
+		sb.WriteString(markdownFlags(hc, "Persistent flags", command.PersistentFlags))
+		fs := command.flags()
+		sb.WriteString(markdownFlags(hc, "Inherited flags", fs.inherit(c.inheritedFlags(command))[len(fs):]))
+		sb.WriteString(markdownExamples(command.Examples))
+		sb.WriteString(markdownExitCodes(command.ExitCodes))
+		if command.DocsURL != "" {
+			sb.WriteString("See also " + command.DocsURL + ".\n\n")
+		}
+		parent := path[:strings.LastIndex(path, " ")]
+		sb.WriteString("Back to [" + parent + "](" + markdownFile(parent) + ").\n")
+		pages[markdownFile(path)] = []byte(sb.String())
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pages, nil
+}
+
+// markdownFile returns the file name of the page for the program or command invoked by path.
+func markdownFile(path string) string {
+	return strings.Replace(path, " ", "-", -1) + ".md"
+}
+
+func markdownCommands(path string, cs CommandSet) string {
+	cs = cs.visible()
+	if len(cs) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## Commands\n\n")
+	for _, command := range cs {
+		commandPath := path + " " + command.Names[0]
+		sb.WriteString("- [`" + command.Names[0] + "`](" + markdownFile(commandPath) + ")")
+		description := command.Description
+		if command.isDeprecated() {
+			description = strings.TrimSpace("[deprecated] " + description)
+		}
+		if description != "" {
+			sb.WriteString(": " + markdownEscape(description))
+		}
+		sb.WriteRune('\n')
+	}
+	sb.WriteRune('\n')
+	return sb.String()
+}
+
+func markdownFlags(hc *HelpConfig, title string, fs FlagSet) string {
+	fs = fs.visible()
+	if len(fs) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## " + title + "\n\n")
+	sb.WriteString("| Flag | Aliases | Description |\n")
+	sb.WriteString("| --- | --- | --- |\n")
+	for _, flag := range fs {
+		var aliases []string
+		for _, alias := range flag.LongAliases {
+			aliases = append(aliases, "`--"+alias+"`")
+		}
+		for _, alias := range flag.aliases() {
+			aliases = append(aliases, "`-"+string(alias)+"`")
+		}
+		name := "`--" + flag.Name + "`"
+		if flag.Type != "" {
+			name = "`--" + flag.Name + " " + flag.Type + "`"
+		}
+		description := markdownEscape(flag.helpDescription(hc))
+		if flag.DocsURL != "" {
+			description = strings.TrimSpace(description + " [More](" + strings.Replace(flag.DocsURL, "|", "%7C", -1) + ")")
+		}
+		sb.WriteString("| " + name + " | " + strings.Join(aliases, ", ") + " | " + description + " |\n")
+	}
+	sb.WriteRune('\n')
+	return sb.String()
+}
+
+func markdownExamples(examples []Example) string {
+	if len(examples) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## Examples\n\n")
+	for _, example := range examples {
+		if example.Description != "" {
+			sb.WriteString(markdownEscape(example.Description) + ":\n\n")
+		}
+		sb.WriteString("```\n" + example.Cmd + "\n```\n\n")
+	}
+	return sb.String()
+}
+
+func markdownExitCodes(exitCodes []ExitCode) string {
+	if len(exitCodes) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	sb.WriteString("## Exit codes\n\n")
+	sb.WriteString("| Code | Description |\n")
+	sb.WriteString("| --- | --- |\n")
+	for _, exitCode := range exitCodes {
+		sb.WriteString("| " + strconv.Itoa(exitCode.Code) + " | " + markdownEscape(exitCode.Description) + " |\n")
+	}
+	sb.WriteRune('\n')
+	return sb.String()
+}
+
+// markdownEscape escapes s for use in a markdown table cell or list item.
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ", "<", "&lt;", ">", "&gt;").Replace(s)
+}
@@ -0,0 +1,34 @@
+package clippy
+
+import "errors"
+
+// Mount returns a command that runs another program, sub, as a subcommand, such as "app sub".
+// The sub-program keeps its own commands, flags, version and authors, which are shown by "app sub --help" and "app sub --version".
+// The command's MinAppVersion and MaxAppVersion can be set to the versions of the program the sub-program is compatible with.
+func Mount(sub *Clippy) *Command {
+	return &Command{
+		Names:       []string{sub.Name},
+		Description: sub.Tagline,
+		mounted:     sub,
+	}
+}
+
+// runMounted runs the mounted program as a subcommand of app. The path is how it was invoked, such as "app sub".
+// It writes to app's Stdout and Stderr unless it has its own, keeps the global flags given to app, and its warnings are app's too.
+// The params are app's from offset on, so errors about them point at where they were in app's params.
+func (c *Command) runMounted(app *Clippy, path string, params []string, offset int) error {
+	sub := *c.mounted
+	sub.Name, sub.parent = path, app
+	if sub.Stdout == nil {
+		sub.Stdout = app.Stdout
+	}
+	if sub.Stderr == nil {
+		sub.Stderr = app.Stderr
+	}
+	err := sub.runE(app.ctx, params)
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) && parseErr.Param >= 1 {
+		parseErr.Param = app.position(offset + parseErr.Param - 1)
+	}
+	return err
+}
@@ -0,0 +1,78 @@
+package clippy_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+	"github.com/patrickmcnamara/clippy/clippytest"
+)
+
+func TestMountedOutput(t *testing.T) {
+	sub := &clippy.Clippy{
+		Name:     "sub",
+		Version:  "2.0.0",
+		Tagline:  "the mounted program",
+		Commands: clippy.CommandSet{{Names: []string{"inner"}, Action: clippy.DefaultAction}},
+	}
+	app := &clippy.Clippy{Name: "app", Version: "1.0.0", HelpWidthFlag: true, Commands: clippy.CommandSet{clippy.Mount(sub)}}
+
+	tests := []struct {
+		params []string
+		want   string
+	}{
+		{[]string{"sub", "--version"}, "2.0.0"},
+		{[]string{"sub", "--help"}, "app sub"},
+		{[]string{"--help-width", "40", "sub", "--help"}, "app sub"},
+	}
+	for _, test := range tests {
+		stdout, _, _, err := clippytest.Execute(app, test.params...)
+		if err != nil {
+			t.Errorf("%q: %v", test.params, err)
+		} else if !strings.Contains(stdout, test.want) {
+			t.Errorf("%q: stdout does not contain %q:\n%s", test.params, test.want, stdout)
+		}
+	}
+}
+
+func TestMountedWarnings(t *testing.T) {
+	sub := &clippy.Clippy{
+		Name:     "sub",
+		Version:  "2.0.0",
+		Commands: clippy.CommandSet{{Names: []string{"inner"}, Flags: clippy.FlagSet{{Name: "old", Kind: clippy.BoolKind, Deprecated: "it does nothing"}}, Action: clippy.DefaultAction}},
+	}
+	app := &clippy.Clippy{Name: "app", Version: "1.0.0", NoWarningsFlag: true, Commands: clippy.CommandSet{clippy.Mount(sub)}}
+
+	tests := []struct {
+		params       []string
+		wantWarnings int
+		wantStderr   string
+	}{
+		{[]string{"sub", "inner"}, 0, ""},
+		{[]string{"sub", "inner", "--old"}, 1, "app sub: warning: flag \"old\" is deprecated"},
+		{[]string{"--no-warnings", "sub", "inner", "--old"}, 1, ""},
+		{[]string{"sub", "--no-warnings", "inner", "--old"}, 1, ""},
+	}
+	for _, test := range tests {
+		_, stderr, _, err := clippytest.Execute(app, test.params...)
+		if err != nil {
+			t.Errorf("%q: %v", test.params, err)
+			continue
+		}
+		if len(app.Warnings()) != test.wantWarnings {
+			t.Errorf("%q: got warnings %q, want %d", test.params, app.Warnings(), test.wantWarnings)
+		}
+		if test.wantStderr == "" && stderr != "" || !strings.Contains(stderr, test.wantStderr) {
+			t.Errorf("%q: got stderr %q, want %q", test.params, stderr, test.wantStderr)
+		}
+	}
+
+	// The mounted program's warnings are its own when it is run alone.
+	if _, _, _, err := clippytest.Execute(sub, "inner", "--old"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`flag "old" is deprecated: it does nothing`}; !reflect.DeepEqual(sub.Warnings(), want) {
+		t.Errorf("got warnings %q, want %q", sub.Warnings(), want)
+	}
+}
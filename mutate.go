@@ -0,0 +1,41 @@
+package clippy
+
+import "fmt"
+
+// AddCommand adds a command to the program.
+// If the program would then fail its check, the error is returned and the program is left unchanged.
+func (c *Clippy) AddCommand(command *Command) error {
+	commands := c.Commands
+	c.Commands = append(append(CommandSet{}, commands...), command)
+	if err := c.Check(); err != nil {
+		c.Commands = commands
+		return err
+	}
+	return nil
+}
+
+// AddFlag adds a global flag to the program.
+// If the program would then fail its check, the error is returned and the program is left unchanged.
+func (c *Clippy) AddFlag(flag *Flag) error {
+	flags := c.Flags
+	c.Flags = append(append(FlagSet{}, flags...), flag)
+	if err := c.Check(); err != nil {
+		c.Flags = flags
+		return err
+	}
+	return nil
+}
+
+// RemoveCommand removes the command with the given name or alias from the program.
+// If there is no such command, an error is returned.
+func (c *Clippy) RemoveCommand(name string) error {
+	for i, command := range c.Commands {
+		for _, commandName := range command.Names {
+			if commandName == name {
+				c.Commands = append(append(CommandSet{}, c.Commands[:i]...), c.Commands[i+1:]...)
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unknown command: %q", name)
+}
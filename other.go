@@ -1,5 +1,10 @@
 package clippy
 
+import (
+	"fmt"
+	"strings"
+)
+
 func longestStringLength(a []string) int {
 	longestLength := 0
 	for _, s := range a {
@@ -10,3 +15,128 @@ func longestStringLength(a []string) int {
 	}
 	return longestLength
 }
+
+// removeParam removes every occurrence of param from params before any "--", returning whether there were any.
+// Params that are the values of flags, such as "--no-warnings" in "--message --no-warnings", are left.
+// The params are the program's, so their positions are removed with them.
+func (c *Clippy) removeParam(params []string, param string) ([]string, bool) {
+	var found bool
+	values := c.valueParams(params)
+	removed := make([]string, 0, len(params))
+	positions := make([]int, 0, len(params))
+	for i, p := range params {
+		if p == "--" {
+			removed = append(removed, params[i:]...)
+			positions = append(positions, c.positions[i:]...)
+			break
+		} else if p == param && !values[i] {
+			found = true
+		} else {
+			removed = append(removed, p)
+			positions = append(positions, c.positions[i])
+		}
+	}
+	c.positions = positions
+	return removed, found
+}
+
+// removeValueParam removes param and the value following it from params, before any "--", returning the value if it was given.
+// The value can also be given like "--param=value". Params that are the values of flags are left.
+// The params are the program's, so their positions are removed with them.
+func (c *Clippy) removeValueParam(params []string, param string) ([]string, string, error) {
+	values := c.valueParams(params)
+	for i, p := range params {
+		if p == "--" {
+			break
+		} else if values[i] {
+			continue
+		} else if strings.HasPrefix(p, param+"=") {
+			c.positions = append(append([]int{}, c.positions[:i]...), c.positions[i+1:]...)
+			return append(append([]string{}, params[:i]...), params[i+1:]...), p[len(param)+1:], nil
+		} else if p != param {
+			continue
+		}
+		if i+1 >= len(params) {
+			return nil, "", fmt.Errorf("no corresponding value for flag: %q", param)
+		}
+		c.positions = append(append([]int{}, c.positions[:i]...), c.positions[i+2:]...)
+		return append(append([]string{}, params[:i]...), params[i+2:]...), params[i+1], nil
+	}
+	return params, "", nil
+}
+
+// valueParams returns which of params are the values of the flags before them, following the commands given to know which flags take values.
+// The params after "--" are not flags, so they are values too.
+func (c *Clippy) valueParams(params []string) []bool {
+	values := make([]bool, len(params))
+	reserved := c.reservedFlags()
+	fs, cs := c.Flags, c.Commands
+	for i := 0; i < len(params); i++ {
+		param := params[i]
+		takesValue := false
+		if param == "--" {
+			for j := i + 1; j < len(params); j++ {
+				values[j] = true
+			}
+			break
+		} else if flag := fs.get(param); flag != nil {
+			takesValue = flag.Kind.takesValue()
+		} else if strings.HasPrefix(param, "--") && reserved[param[2:]] {
+			takesValue = true
+		} else if n := fs.clusterParams(param); n >= 1 {
+			takesValue = n == 2
+		} else if command := cs.get(param); command != nil && command.mounted != nil {
+			fs, cs = command.mounted.Flags, command.mounted.Commands
+		} else if command != nil {
+			fs = command.flags()
+			fs, cs = fs.inherit(c.inheritedFlags(command)), command.Commands
+		}
+		if takesValue && i+1 < len(params) {
+			values[i+1] = true
+			i++
+		}
+	}
+	return values
+}
+
+// position returns the position of the program's param at i in the params it was run with, counting from 1, or 0 if it came from OptsEnv.
+func (c *Clippy) position(i int) int {
+	if i < 0 || i >= len(c.positions) {
+		return 0
+	}
+	return c.positions[i]
+}
+
+// alignPositions returns the positions of after, a rewrite of before, keeping the positions of the params that the rewrite kept in order.
+// The params that it added or changed have position 0.
+func alignPositions(before []string, positions []int, after []string) []int {
+	// Find the longest common subsequence of the params, from the end.
+	lengths := make([][]int, len(before)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(after)+1)
+	}
+	for i := len(before) - 1; i >= 0; i-- {
+		for j := len(after) - 1; j >= 0; j-- {
+			if before[i] == after[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	aligned := make([]int, len(after))
+	for i, j := 0, 0; i < len(before) && j < len(after); {
+		if before[i] == after[j] {
+			aligned[j] = positions[i]
+			i, j = i+1, j+1
+		} else if lengths[i+1][j] >= lengths[i][j+1] {
+			i++
+		} else {
+			j++
+		}
+	}
+	return aligned
+}
@@ -0,0 +1,89 @@
+package clippy
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// stdout returns where the program writes output, which is Stdout or os.Stdout.
+func (c *Clippy) stdout() io.Writer {
+	if c.Stdout != nil {
+		return c.Stdout
+	}
+	return os.Stdout
+}
+
+// stderr returns where the program writes errors, warnings and prompts, which is Stderr or os.Stderr.
+func (c *Clippy) stderr() io.Writer {
+	if c.Stderr != nil {
+		return c.Stderr
+	}
+	return os.Stderr
+}
+
+// println writes s and a newline to stdout. A write error, such as a closed pipe, is returned as an *ActionError.
+func (c *Clippy) println(s string) error {
+	return c.fprintln(c.stdout(), s)
+}
+
+// fprintln writes s and a newline to w, returning a write error as an *ActionError like println.
+func (c *Clippy) fprintln(w io.Writer, s string) error {
+	if _, err := fmt.Fprintln(w, s); err != nil {
+		return &ActionError{err}
+	}
+	return nil
+}
+
+// WriteHelp writes the program's help to w, such as for a TUI or web console to show it.
+// It is wrapped like the help the program prints, so HelpConfig's LineWidth can be set to fit w.
+func (c *Clippy) WriteHelp(w io.Writer) error {
+	if err := c.Check(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, c.help(false))
+	return err
+}
+
+// WriteCommandHelp writes the help of the command invoked by path to w, such as "remote add" for "app remote add".
+// The path can use the commands' aliases. It is an error if there is no such command.
+func (c *Clippy) WriteCommandHelp(w io.Writer, path string) error {
+	if err := c.Check(); err != nil {
+		return err
+	}
+	help, err := c.commandHelp(strings.Fields(path), false)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, help)
+	return err
+}
+
+// commandHelp returns the help of the command invoked by the names, or the program's help if there are none.
+// The names can be the commands' aliases. It is an error if there is no such command.
+func (c *Clippy) commandHelp(names []string, all bool) (string, error) {
+	if len(names) == 0 {
+		return c.help(all), nil
+	}
+	command := c.Commands.get(names[0])
+	if command == nil {
+		return "", fmt.Errorf("unknown command %q", c.Name+" "+names[0])
+	}
+	commandPath := c.commandPath(command)
+	for len(names) > 1 && command.mounted == nil {
+		sub := command.Commands.get(names[1])
+		if sub == nil {
+			return "", fmt.Errorf("unknown command %q", commandPath+" "+names[1])
+		}
+		command, commandPath, names = sub, commandPath+" "+sub.Names[0], names[1:]
+	}
+
+	// Leave the rest of the path to a mounted program.
+	if command.mounted != nil {
+		sub := *command.mounted
+		sub.Name = commandPath
+		return sub.commandHelp(names[1:], all)
+	}
+	return command.help(c, commandPath, all), nil
+}
@@ -0,0 +1,80 @@
+package clippy_test
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+	"github.com/patrickmcnamara/clippy/clippytest"
+)
+
+func TestBinaryStdout(t *testing.T) {
+	const data = "\x89PNG\x00\x01"
+	app := &clippy.Clippy{Name: "app", Version: "1.0.0", PrintCmdFlag: true}
+	app.Commands = clippy.CommandSet{{
+		Names:        []string{"export"},
+		BinaryStdout: true,
+		Flags:        clippy.FlagSet{{Name: "old", Kind: clippy.BoolKind, Deprecated: "it does nothing"}},
+		Action: func(flags clippy.Flags, args []string) error {
+			_, err := app.Stdout.Write([]byte(data))
+			return err
+		},
+	}}
+
+	tests := []struct {
+		params     []string
+		wantStdout string
+		wantStderr []string
+	}{
+		{[]string{"--print-command", "export", "--old"}, data, []string{"app export --old\n", "warning: flag \"old\" is deprecated"}},
+		{[]string{"export", "--help"}, "", []string{"USAGE:", "Writes binary data to stdout"}},
+		{[]string{"export", "--help-all"}, "", []string{"USAGE:"}},
+	}
+	for _, test := range tests {
+		stdout, stderr, _, err := clippytest.Execute(app, test.params...)
+		if err != nil {
+			t.Errorf("%q: %v", test.params, err)
+			continue
+		}
+		if stdout != test.wantStdout {
+			t.Errorf("%q: wrote %q to stdout, want %q", test.params, stdout, test.wantStdout)
+		}
+		for _, want := range test.wantStderr {
+			if !strings.Contains(stderr, want) {
+				t.Errorf("%q: stderr does not contain %q:\n%s", test.params, want, stderr)
+			}
+		}
+	}
+}
+
+func TestWarningWriters(t *testing.T) {
+	// Programs run at the same time each write their warnings to their own Stderr.
+	var wg sync.WaitGroup
+	for _, name := range []string{"one", "two", "three"} {
+		name := name
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var stderr bytes.Buffer
+			app := &clippy.Clippy{Name: name, Version: "1.0.0", Stderr: &stderr, Action: clippy.DefaultAction}
+			app.Action = func(flags clippy.Flags, args []string) error {
+				for i := 0; i < 100; i++ {
+					app.Warn("warning %d", i)
+				}
+				return nil
+			}
+			if err := app.RunE(nil); err != nil {
+				t.Errorf("%s: %v", name, err)
+			}
+			for _, line := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+				if !strings.HasPrefix(line, name+": warning: ") {
+					t.Errorf("%s: got warning %q", name, line)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
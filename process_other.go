@@ -0,0 +1,29 @@
+//go:build windows || plan9 || js
+// +build windows plan9 js
+
+package clippy
+
+import (
+	"os"
+	"strings"
+)
+
+// shutdownSignals are the signals that cancel the context given to RunContext.
+var shutdownSignals = []os.Signal{os.Interrupt}
+
+// isBrokenPipe returns whether err is from writing to a closed pipe.
+// There is no portable error for it, so it is recognized by its message.
+func isBrokenPipe(err error) bool {
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "pipe is being closed")
+}
+
+// processExists returns whether a process with the given pid is running.
+func processExists(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
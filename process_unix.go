@@ -0,0 +1,24 @@
+//go:build !windows && !plan9 && !js
+// +build !windows,!plan9,!js
+
+package clippy
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// shutdownSignals are the signals that cancel the context given to RunContext.
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// isBrokenPipe returns whether err is from writing to a closed pipe.
+func isBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE)
+}
+
+// processExists returns whether a process with the given pid is running.
+func processExists(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
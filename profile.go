@@ -0,0 +1,133 @@
+package clippy
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// profileFlags are the values of the hidden profiling global flags.
+type profileFlags struct {
+	cpuProfile string // cpuProfile is the file given by "--cpuprofile".
+	memProfile string // memProfile is the file given by "--memprofile".
+	pprofAddr  string // pprofAddr is the address given by "--pprof-addr".
+}
+
+// removeProfileFlags removes the profiling global flags from params, returning their values.
+func (c *Clippy) removeProfileFlags(params []string) ([]string, profileFlags, error) {
+	var pf profileFlags
+	var err error
+	for _, flag := range []struct {
+		name  string
+		value *string
+	}{
+		{"--cpuprofile", &pf.cpuProfile},
+		{"--memprofile", &pf.memProfile},
+		{"--pprof-addr", &pf.pprofAddr},
+	} {
+		if params, *flag.value, err = c.removeValueParam(params, flag.name); err != nil {
+			return nil, pf, err
+		}
+	}
+	return params, pf, nil
+}
+
+// start starts the profiling asked for by the profiling global flags, returning a function that stops it.
+func (pf *profileFlags) start() (stop func() error, err error) {
+	var stops []func() error
+	stop = func() error {
+		var err error
+		for i := len(stops) - 1; i >= 0; i-- {
+			if serr := stops[i](); err == nil {
+				err = serr
+			}
+		}
+		return err
+	}
+
+	// Serve profiles over HTTP.
+	if pf.pprofAddr != "" {
+		l, err := net.Listen("tcp", pf.pprofAddr)
+		if err != nil {
+			return nil, err
+		}
+		go http.Serve(l, http.HandlerFunc(servePprof))
+		stops = append(stops, l.Close)
+	}
+
+	// Profile the CPU.
+	if pf.cpuProfile != "" {
+		f, err := os.Create(pf.cpuProfile)
+		if err != nil {
+			stop()
+			return nil, err
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			stop()
+			return nil, err
+		}
+		stops = append(stops, func() error {
+			pprof.StopCPUProfile()
+			return f.Close()
+		})
+	}
+
+	// Profile the heap when stopping.
+	if pf.memProfile != "" {
+		stops = append(stops, func() error {
+			f, err := os.Create(pf.memProfile)
+			if err != nil {
+				return err
+			}
+			runtime.GC()
+			if err := pprof.WriteHeapProfile(f); err != nil {
+				f.Close()
+				return err
+			}
+			return f.Close()
+		})
+	}
+
+	return stop, nil
+}
+
+// servePprof serves the runtime profiles at "/debug/pprof/<name>", and a CPU profile at "/debug/pprof/profile?seconds=N".
+// It avoids net/http/pprof, which registers itself on http.DefaultServeMux.
+func servePprof(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/debug/pprof/")
+	switch name {
+	case "profile":
+		seconds, err := strconv.Atoi(r.FormValue("seconds"))
+		if err != nil || seconds <= 0 {
+			seconds = 30
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		if err := pprof.StartCPUProfile(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		time.Sleep(time.Duration(seconds) * time.Second)
+		pprof.StopCPUProfile()
+	case "", "/debug/pprof":
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		for _, p := range pprof.Profiles() {
+			fmt.Fprintf(w, "/debug/pprof/%s\t%d\n", p.Name(), p.Count())
+		}
+		fmt.Fprintln(w, "/debug/pprof/profile")
+	default:
+		p := pprof.Lookup(name)
+		if p == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		p.WriteTo(w, 0)
+	}
+}
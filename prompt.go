@@ -0,0 +1,96 @@
+package clippy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PromptHook returns a snippet for the given shell, "bash", "zsh" or "fish", defining a function to use in the shell prompt.
+// The function prints the program's status, such as the current profile, by running the command made by PromptHookCommand.
+func (c *Clippy) PromptHook(shell string) (string, error) {
+	fn := shellIdentifier(c.Name) + "_prompt"
+	status := quote(c.Name) + " prompt-hook --status 2>/dev/null"
+	switch shell {
+	case "bash", "zsh":
+		return fn + "() {\n\t" + status + "\n}\n", nil
+	case "fish":
+		return "function " + fn + "\n\t" + status + "\nend\n", nil
+	}
+	return "", fmt.Errorf("unsupported shell: %q", shell)
+}
+
+// PromptHookCommand returns a "prompt-hook" command that prints a shell prompt snippet using PromptHook.
+// The snippet runs the command with the "--status" flag, which prints the result of status.
+// The result is cached in the program's cache directory for ttl, so prompts stay fast however slow status is.
+// It can be added to the program's Commands.
+func (c *Clippy) PromptHookCommand(status func() (string, error), ttl time.Duration) *Command {
+	return &Command{
+		Names:       []string{"prompt-hook"},
+		Description: "print a shell prompt snippet for bash, zsh or fish",
+		Args:        []string{"SHELL"},
+		Flags: FlagSet{
+			{Name: "status", Kind: BoolKind, Description: "print the status shown in the prompt", Advanced: true},
+		},
+		Action: func(flags Flags, args []string) error {
+			if flags.GetBool("status") {
+				s, err := c.promptStatus(status, ttl)
+				if err != nil {
+					return err
+				}
+				_, err = fmt.Fprint(c.stdout(), s)
+				return err
+			}
+			if len(args) != 1 {
+				return fmt.Errorf("missing shell: use \"bash\", \"zsh\" or \"fish\"")
+			}
+			hook, err := c.PromptHook(args[0])
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprint(c.stdout(), hook)
+			return err
+		},
+	}
+}
+
+// promptStatus returns the result of status, from the cache if it is younger than ttl.
+func (c *Clippy) promptStatus(status func() (string, error), ttl time.Duration) (string, error) {
+	dir, err := c.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "prompt-status")
+
+	// Use the cached status if it is fresh.
+	if fi, err := os.Stat(path); err == nil && time.Since(fi.ModTime()) < ttl {
+		if b, err := ioutil.ReadFile(path); err == nil {
+			return string(b), nil
+		}
+	}
+
+	s, err := status()
+	if err != nil {
+		return "", err
+	}
+
+	// Replace the cache atomically, so concurrent prompts never read a partial status.
+	f, err := ioutil.TempFile(dir, "prompt-status-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.WriteString(s)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return s, nil
+}
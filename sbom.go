@@ -0,0 +1,60 @@
+package clippy
+
+import (
+	"encoding/json"
+	"errors"
+	"runtime"
+	"runtime/debug"
+)
+
+// sbom is the inventory of the program printed by "--version --sbom", for security teams to find what is deployed.
+type sbom struct {
+	Name      string            `json:"name"`
+	Version   string            `json:"version"`
+	GoVersion string            `json:"go_version"`
+	Path      string            `json:"path"`
+	Main      sbomModule        `json:"main"`
+	Deps      []sbomModule      `json:"deps"`
+	VCS       map[string]string `json:"vcs,omitempty"`
+}
+
+// sbomModule is a module the program was built from.
+type sbomModule struct {
+	Path    string      `json:"path"`
+	Version string      `json:"version"`
+	Sum     string      `json:"sum,omitempty"`
+	Replace *sbomModule `json:"replace,omitempty"`
+}
+
+func newSBOMModule(m *debug.Module) sbomModule {
+	module := sbomModule{Path: m.Path, Version: m.Version, Sum: m.Sum}
+	if m.Replace != nil {
+		replace := newSBOMModule(m.Replace)
+		module.Replace = &replace
+	}
+	return module
+}
+
+// sbom returns the program's inventory as JSON, from the build information embedded in the executable.
+func (c *Clippy) sbom() (string, error) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "", errors.New("no build information in executable")
+	}
+
+	s := sbom{
+		Name:      c.Name,
+		Version:   c.Version,
+		GoVersion: runtime.Version(),
+		Path:      bi.Path,
+		Main:      newSBOMModule(&bi.Main),
+		Deps:      make([]sbomModule, 0, len(bi.Deps)),
+		VCS:       vcsSettings(bi),
+	}
+	for _, dep := range bi.Deps {
+		s.Deps = append(s.Deps, newSBOMModule(dep))
+	}
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	return string(b), err
+}
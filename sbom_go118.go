@@ -0,0 +1,22 @@
+//go:build go1.18
+// +build go1.18
+
+package clippy
+
+import (
+	"runtime/debug"
+	"strings"
+)
+
+// vcsSettings returns the version control information stamped into the executable, such as the revision.
+func vcsSettings(bi *debug.BuildInfo) map[string]string {
+	vcs := make(map[string]string)
+	for _, setting := range bi.Settings {
+		if key := strings.TrimPrefix(setting.Key, "vcs."); key != setting.Key {
+			vcs[key] = setting.Value
+		} else if setting.Key == "vcs" {
+			vcs["system"] = setting.Value
+		}
+	}
+	return vcs
+}
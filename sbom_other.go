@@ -0,0 +1,11 @@
+//go:build !go1.18
+// +build !go1.18
+
+package clippy
+
+import "runtime/debug"
+
+// vcsSettings returns nothing, since version control information is only stamped into executables since Go 1.18.
+func vcsSettings(bi *debug.BuildInfo) map[string]string {
+	return nil
+}
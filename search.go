@@ -0,0 +1,56 @@
+package clippy
+
+import (
+	"fmt"
+	"strings"
+)
+
+// search returns the commands, flags and examples whose names, commands or descriptions contain the keyword, ignoring case.
+func (c *Clippy) search(keyword string) string {
+	keyword = strings.ToLower(keyword)
+	matches := func(strs ...string) bool {
+		for _, s := range strs {
+			if strings.Contains(strings.ToLower(s), keyword) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var entries []helpEntry
+	searchFlags := func(path string, fs FlagSet) {
+		for _, flag := range fs.visible() {
+			if matches(flag.Name, flag.Description) {
+				entries = append(entries, helpEntry{name: path + " --" + flag.Name, description: flag.Description})
+			}
+		}
+	}
+
+	searchExamples := func(examples []Example) {
+		for _, example := range examples {
+			if matches(example.Cmd, example.Description) {
+				entries = append(entries, helpEntry{name: example.Cmd, description: example.Description})
+			}
+		}
+	}
+
+	searchFlags(c.Name, c.Flags)
+	searchExamples(c.Examples)
+	c.Commands.walkMounted(c.Name, func(path string, command *Command) {
+		if matches(command.Names[0]) || matches(command.aliases()...) || matches(command.Description) {
+			entries = append(entries, helpEntry{name: path, description: command.Description})
+		}
+		if command.mounted != nil {
+			searchFlags(path, command.mounted.Flags)
+			searchExamples(command.mounted.Examples)
+		} else {
+			searchFlags(path, command.flags())
+		}
+		searchExamples(command.Examples)
+	})
+
+	if len(entries) == 0 {
+		return fmt.Sprintf("no matches for %q", keyword)
+	}
+	return strings.TrimRight(c.helpConfig().table(entries), "\n")
+}
@@ -0,0 +1,32 @@
+package clippy_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy/clippytest"
+)
+
+func TestHelpSearch(t *testing.T) {
+	tests := []struct {
+		keyword string
+		want    []string
+	}{
+		{"build", []string{"app build"}},
+		{"mounted", []string{"app sub --sub-flag", "app sub inner", "app sub --sub-flag x"}},
+		{"INNER", []string{"app sub inner", "app sub inner --inner-flag"}},
+		{"nothing like it", []string{`no matches for "nothing like it"`}},
+	}
+	for _, test := range tests {
+		stdout, _, _, err := clippytest.Execute(newMountingApp(), "help", "--search", test.keyword)
+		if err != nil {
+			t.Errorf("%q: %v", test.keyword, err)
+			continue
+		}
+		for _, want := range test.want {
+			if !strings.Contains(stdout, want) {
+				t.Errorf("%q: search does not find %q:\n%s", test.keyword, want, stdout)
+			}
+		}
+	}
+}
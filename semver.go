@@ -0,0 +1,120 @@
+package clippy
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CompareVersions compares two semantic versions, such as "1.2.3" or "v2.0.0-rc.1", ignoring build metadata.
+// It returns -1 if a is less than b, 0 if they are equal, and 1 if a is greater than b.
+// It returns an error if either is not a valid semantic version.
+func CompareVersions(a, b string) (int, error) {
+	va, err := parseVersion(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parseVersion(b)
+	if err != nil {
+		return 0, err
+	}
+
+	// Compare major, minor and patch versions.
+	for i := 0; i < 3; i++ {
+		if va.core[i] != vb.core[i] {
+			return compareInts(va.core[i], vb.core[i]), nil
+		}
+	}
+
+	// A version without a prerelease is greater than one with a prerelease.
+	switch {
+	case len(va.prerelease) == 0 && len(vb.prerelease) == 0:
+		return 0, nil
+	case len(va.prerelease) == 0:
+		return 1, nil
+	case len(vb.prerelease) == 0:
+		return -1, nil
+	}
+
+	// Compare prerelease identifiers one by one.
+	for i := 0; i < len(va.prerelease) && i < len(vb.prerelease); i++ {
+		pa, pb := va.prerelease[i], vb.prerelease[i]
+		na, aErr := strconv.Atoi(pa)
+		nb, bErr := strconv.Atoi(pb)
+		switch {
+		case aErr == nil && bErr == nil:
+			if na != nb {
+				return compareInts(na, nb), nil
+			}
+		case aErr == nil:
+			return -1, nil
+		case bErr == nil:
+			return 1, nil
+		case pa != pb:
+			return strings.Compare(pa, pb), nil
+		}
+	}
+	return compareInts(len(va.prerelease), len(vb.prerelease)), nil
+}
+
+// ValidateVersion returns an error if v is not a valid semantic version, such as "1.2.3" or "v2.0.0-rc.1+abc".
+func ValidateVersion(v string) error {
+	_, err := parseVersion(v)
+	return err
+}
+
+// version is a parsed semantic version.
+type version struct {
+	core       [3]int
+	prerelease []string
+}
+
+func parseVersion(s string) (v version, err error) {
+	invalid := fmt.Errorf("invalid semantic version: %q", s)
+
+	// Remove the optional "v" prefix and build metadata.
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexRune(s, '+'); i != -1 {
+		if i == len(s)-1 {
+			return v, invalid
+		}
+		s = s[:i]
+	}
+
+	// Split off the prerelease.
+	if i := strings.IndexRune(s, '-'); i != -1 {
+		v.prerelease = strings.Split(s[i+1:], ".")
+		for _, id := range v.prerelease {
+			if id == "" {
+				return v, invalid
+			}
+		}
+		s = s[:i]
+	}
+
+	// Parse major, minor and patch versions.
+	core := strings.Split(s, ".")
+	if len(core) != 3 {
+		return v, invalid
+	}
+	for i, n := range core {
+		if n == "" || len(n) > 1 && n[0] == '0' {
+			return v, invalid
+		}
+		if v.core[i], err = strconv.Atoi(n); err != nil || v.core[i] < 0 {
+			return v, invalid
+		}
+	}
+
+	return v, nil
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,136 @@
+package clippy
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// splitShell splits s into words the way a POSIX shell would, honoring single quotes, double quotes and backslashes.
+// It does not expand variables or globs.
+func splitShell(s string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	var inWord bool
+	var quote rune
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else if r == '\\' && i+1 < len(runes) && strings.ContainsRune("$`\"\\\n", runes[i+1]) {
+				i++
+				word.WriteRune(runes[i])
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '\\':
+			if i+1 >= len(runes) {
+				return nil, errors.New("trailing backslash")
+			}
+			i++
+			word.WriteRune(runes[i])
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// Quote joins params into a single line, quoting each of them as needed so that a POSIX shell would split it back into the same params.
+func Quote(params ...string) string {
+	quoted := make([]string, len(params))
+	for i, param := range params {
+		quoted[i] = quote(param)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func quote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// printCommand prints the invocation to stderr, if the "--print-command" global flag was given.
+// Flags are printed with the values given in the params, so values taken from the environment, the config file, stdin or prompts, which may be secret, are left out, as are transformed values.
+// The profile of the config file is printed however it was selected, since it decides the values taken from the file.
+func (c *Clippy) printCommand(path string, fs FlagSet, flags Flags, args []string) {
+	if !c.printingCommand {
+		return
+	}
+	params := strings.Split(path, " ")
+	if c.configProfile != "" {
+		params = append(params, "--profile", c.configProfile)
+	}
+	for _, f := range fs {
+		raw, ok := flags.raw[f.Name]
+		if !ok {
+			continue
+		}
+		last := raw[len(raw)-1]
+		switch {
+		case f.Kind == BoolKind:
+			if b, _ := strconv.ParseBool(last); b {
+				params = append(params, "--"+f.Name)
+			} else {
+				params = append(params, "--"+f.Name+"=false")
+			}
+		case f.Kind == CountKind:
+			params = append(params, "--"+f.Name+"="+last)
+		case f.Repeatable:
+			for _, value := range raw {
+				params = append(params, "--"+f.Name, value)
+			}
+		default:
+			params = append(params, "--"+f.Name, last)
+		}
+	}
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-") {
+			params = append(params, "--")
+			break
+		}
+	}
+	params = append(params, args...)
+	fmt.Fprintln(c.stderr(), Quote(params...))
+}
@@ -0,0 +1,65 @@
+package clippy_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+	"github.com/patrickmcnamara/clippy/clippytest"
+)
+
+func TestPrintCommand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "clippy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(config, []byte(`{"region": "eu-west-1"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv("APP_TEST_TOKEN", "secret")
+	defer os.Unsetenv("APP_TEST_TOKEN")
+
+	app := &clippy.Clippy{
+		Name:         "app",
+		Version:      "1.0.0",
+		ConfigFile:   config,
+		PrintCmdFlag: true,
+		Commands: clippy.CommandSet{{
+			Names: []string{"deploy"},
+			Flags: clippy.FlagSet{
+				{Name: "token", EnvVar: "APP_TEST_TOKEN"},
+				{Name: "region"},
+				{Name: "env", Transform: func(value string) (string, error) { return strings.ToUpper(value), nil }},
+				{Name: "tag", Repeatable: true},
+				{Name: "force", Kind: clippy.BoolKind},
+				{Name: "verbose", Alias: 'v', Kind: clippy.CountKind},
+				{Name: "replicas", Kind: clippy.IntKind, DefaultValue: "1"},
+			},
+			Action: clippy.DefaultAction,
+		}},
+	}
+
+	tests := []struct {
+		params []string
+		want   string
+	}{
+		{[]string{"--print-command", "deploy"}, "app deploy\n"},
+		{[]string{"--print-command", "deploy", "--env", "prod", "x y"}, "app deploy --env prod 'x y'\n"},
+		{[]string{"--print-command", "deploy", "--tag", "a", "--tag=b", "-vv", "--force=false"}, "app deploy --tag a --tag b --force=false --verbose=2\n"},
+		{[]string{"--print-command", "deploy", "--force", "--", "-x"}, "app deploy --force -- -x\n"},
+		{[]string{"--print-command", "deploy", "--token", "given", "--region", "us-east-1"}, "app deploy --token given --region us-east-1\n"},
+	}
+	for _, test := range tests {
+		_, stderr, _, err := clippytest.Execute(app, test.params...)
+		if err != nil {
+			t.Errorf("%q: %v", test.params, err)
+		} else if stderr != test.want {
+			t.Errorf("%q: printed %q, want %q", test.params, stderr, test.want)
+		}
+	}
+}
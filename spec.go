@@ -0,0 +1,104 @@
+package clippy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// Spec describes the public surface of a program: its commands, flags, their kinds and defaults.
+// It leaves out descriptions and the version, which change without breaking users, so it suits contract tests.
+type Spec struct {
+	Name     string        `json:"name"`
+	Args     []string      `json:"args,omitempty"`
+	Flags    []FlagSpec    `json:"flags,omitempty"`
+	Commands []CommandSpec `json:"commands,omitempty"`
+}
+
+// CommandSpec describes a command in a Spec.
+type CommandSpec struct {
+	Names    []string      `json:"names"`
+	Hidden   bool          `json:"hidden,omitempty"`
+	Args     []string      `json:"args,omitempty"`
+	Flags    []FlagSpec    `json:"flags,omitempty"`
+	Commands []CommandSpec `json:"commands,omitempty"`
+}
+
+// FlagSpec describes a flag in a Spec.
+type FlagSpec struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases,omitempty"`
+	LongAliases []string `json:"long_aliases,omitempty"`
+	Kind        string   `json:"kind"`
+	Type        string   `json:"type,omitempty"`
+	Default     string   `json:"default,omitempty"`
+	EnvVar      string   `json:"env_var,omitempty"`
+	Choices     []string `json:"choices,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Repeatable  bool     `json:"repeatable,omitempty"`
+	Delimiter   string   `json:"delimiter,omitempty"`
+	Completion  string   `json:"completion,omitempty"`
+	Hidden      bool     `json:"hidden,omitempty"`
+}
+
+// Spec returns the public surface of the program, including hidden commands and flags and mounted programs.
+func (c *Clippy) Spec() *Spec {
+	return &Spec{
+		Name:     c.Name,
+		Args:     c.Args,
+		Flags:    flagSpecs(c.Flags),
+		Commands: commandSpecs(c.Commands),
+	}
+}
+
+// Hash returns a stable digest of the spec, as hex, that changes whenever the spec does.
+// Tests can compare it against a recorded digest to catch unintended changes to the program's surface.
+func (s *Spec) Hash() string {
+	b, _ := json.Marshal(s)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func commandSpecs(cs CommandSet) []CommandSpec {
+	var specs []CommandSpec
+	for _, command := range cs {
+		spec := CommandSpec{Names: command.Names, Hidden: command.Hidden, Args: command.Args}
+		if command.mounted != nil {
+			spec.Args = command.mounted.Args
+			spec.Flags = flagSpecs(command.mounted.Flags)
+			spec.Commands = commandSpecs(command.mounted.Commands)
+		} else {
+			spec.Flags = flagSpecs(command.flags())
+			spec.Commands = commandSpecs(command.Commands)
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
+
+func flagSpecs(fs FlagSet) []FlagSpec {
+	var specs []FlagSpec
+	for _, flag := range fs {
+		spec := FlagSpec{
+			Name:        flag.Name,
+			LongAliases: flag.LongAliases,
+			Kind:        flag.Kind.String(),
+			Type:        flag.Type,
+			EnvVar:      flag.EnvVar,
+			Choices:     flag.Choices,
+			Required:    flag.Required,
+			Repeatable:  flag.Repeatable,
+			Delimiter:   flag.Delimiter,
+			Completion:  flag.Completion,
+			Hidden:      flag.Hidden,
+		}
+		if flag.DefaultValue != EmptyValue {
+			spec.Default = flag.DefaultValue
+		}
+		for _, alias := range flag.aliases() {
+			spec.Aliases = append(spec.Aliases, string(alias))
+		}
+		specs = append(specs, spec)
+	}
+	return specs
+}
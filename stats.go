@@ -0,0 +1,134 @@
+package clippy
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// usageStats counts how often commands and flags have been used, for the "stats" command made by StatsCommand.
+type usageStats struct {
+	Commands map[string]int `json:"commands"` // Commands counts runs by how the command was invoked, such as "app build".
+	Flags    map[string]int `json:"flags"`    // Flags counts uses of each flag by command, such as "app build --release".
+}
+
+// statsFile is the name of the file in the state directory that usage stats are kept in.
+const statsFile = "usage-stats.json"
+
+// recordUsage counts a run of the program or command invoked by path with flags, if UsageStats is set.
+// It is best effort, so failing to record never stops the program.
+func (c *Clippy) recordUsage(path string, flags Flags) {
+	if !c.UsageStats {
+		return
+	}
+	dir, err := c.StateDir()
+	if err != nil {
+		return
+	}
+	stats := readUsageStats(filepath.Join(dir, statsFile))
+	stats.Commands[path]++
+	for name := range flags.given {
+		stats.Flags[path+" --"+name]++
+	}
+	b, err := json.Marshal(stats)
+	if err != nil {
+		return
+	}
+
+	// Replace the file atomically, so concurrent runs never read partial stats.
+	f, err := ioutil.TempFile(dir, "usage-stats-*")
+	if err != nil {
+		return
+	}
+	_, err = f.Write(b)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(dir, statsFile))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+}
+
+// readUsageStats reads the usage stats kept at path, or returns empty stats if there are none.
+func readUsageStats(path string) usageStats {
+	var stats usageStats
+	if b, err := ioutil.ReadFile(path); err == nil {
+		json.Unmarshal(b, &stats)
+	}
+	if stats.Commands == nil {
+		stats.Commands = make(map[string]int)
+	}
+	if stats.Flags == nil {
+		stats.Flags = make(map[string]int)
+	}
+	return stats
+}
+
+// StatsCommand returns a "stats" command that shows how often each command and flag has been used, most used first.
+// The counts are only kept if UsageStats is set, and never leave the user's machine. They help decide what to deprecate.
+// It can be added to the program's Commands.
+func (c *Clippy) StatsCommand() *Command {
+	return &Command{
+		Names:       []string{"stats"},
+		Description: "show how often each command and flag has been used",
+		Flags: FlagSet{
+			{Name: "reset", Kind: BoolKind, Description: "forget the usage counted so far"},
+		},
+		Action: func(flags Flags, args []string) error {
+			dir, err := c.StateDir()
+			if err != nil {
+				return err
+			}
+			path := filepath.Join(dir, statsFile)
+			if flags.GetBool("reset") {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				return nil
+			}
+
+			stats := readUsageStats(path)
+			if len(stats.Commands) == 0 {
+				if !c.UsageStats {
+					return c.println("no usage counted, since usage stats are turned off")
+				}
+				return c.println("no usage counted yet")
+			}
+			hc := c.helpConfig()
+			var sb strings.Builder
+			sb.WriteString("COMMANDS:\n")
+			sb.WriteString(hc.table(statsEntries(stats.Commands)))
+			if len(stats.Flags) >= 1 {
+				sb.WriteString("\nFLAGS:\n")
+				sb.WriteString(hc.table(statsEntries(stats.Flags)))
+			}
+			return c.println(strings.TrimRight(sb.String(), "\n"))
+		},
+	}
+}
+
+// statsEntries returns counts as help entries, most used first.
+func statsEntries(counts map[string]int) []helpEntry {
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	entries := make([]helpEntry, len(names))
+	for i, name := range names {
+		entries[i] = helpEntry{name: name, description: strconv.Itoa(counts[name])}
+	}
+	return entries
+}
@@ -0,0 +1,72 @@
+package clippy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Line is a line of output from a StreamSource.
+type Line struct {
+	Time time.Time // Time the line was produced. If it is zero, it is the time the line is written.
+	Text string    // Text of the line, without its newline.
+}
+
+// StreamQuery is what a StreamSource is asked for, from the flags given to the command.
+type StreamQuery struct {
+	Since time.Time // Since is the earliest time of the lines to emit, or zero for every line.
+	Tail  int       // Tail is how many of the lines produced so far to emit before following, or -1 for every line.
+}
+
+// StreamSource emits the lines of a stream, such as the logs of a service, until there are no more or ctx is done.
+// It should return as soon as emit returns an error, which is ctx's error once it is done or the error writing the line.
+type StreamSource func(ctx context.Context, query StreamQuery, emit func(line Line) error) error
+
+// Stream returns the flags and action of a follow-logs style command, which writes the lines emitted by source to stdout as they come.
+// The flags are "--since", for lines produced within a duration, "--tail", for only the last lines produced so far, and "--timestamps", to prefix each line with its time.
+// Being interrupted while following is how the stream is stopped, so it is not an error if the program was run with RunContext.
+func (c *Clippy) Stream(source StreamSource) (FlagSet, ActionCtx) {
+	fs := FlagSet{
+		{Name: "since", Type: "DURATION", Kind: DurationKind, Description: "show only lines produced within the duration, such as 10m"},
+		{Name: "tail", Type: "N", Kind: IntKind, DefaultValue: "-1", Description: "show only the last N lines produced so far, or -1 for every line", Validate: func(value string) error {
+			if n, _ := strconv.ParseInt(value, 0, 0); n < -1 {
+				return fmt.Errorf("%d is less than -1", n)
+			}
+			return nil
+		}},
+		{Name: "timestamps", Alias: 't', Kind: BoolKind, Description: "prefix each line with the time it was produced"},
+	}
+	return fs, func(ctx context.Context, flags Flags, args []string) error {
+		query := StreamQuery{Tail: flags.GetInt("tail")}
+		if d := flags.GetDuration("since"); d > 0 {
+			query.Since = time.Now().Add(-d)
+		}
+		timestamps := flags.GetBool("timestamps")
+
+		emit := func(line Line) error {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if line.Time.IsZero() {
+				line.Time = time.Now()
+			}
+			// Leave out lines from before --since, in case source does not.
+			if line.Time.Before(query.Since) {
+				return nil
+			}
+			text := line.Text
+			if timestamps {
+				text = line.Time.Format(time.RFC3339) + " " + text
+			}
+			_, err := fmt.Fprintln(c.stdout(), text)
+			return err
+		}
+
+		err := source(ctx, query, emit)
+		if err != nil && ctx.Err() == context.Canceled {
+			return nil
+		}
+		return err
+	}
+}
@@ -0,0 +1,91 @@
+package clippy
+
+import "fmt"
+
+// Suggester suggests what a mistyped command name was meant to be, such as "status" for "stauts".
+type Suggester interface {
+	// Suggest returns the candidate that name was most likely meant to be, or an empty string if none is likely enough.
+	// The candidates are the names and visible aliases of the commands that are not deprecated or hidden, in order.
+	Suggest(name string, candidates []string) string
+}
+
+// SuggesterFunc is a function that is a Suggester.
+type SuggesterFunc func(name string, candidates []string) string
+
+// Suggest calls f(name, candidates).
+func (f SuggesterFunc) Suggest(name string, candidates []string) string { return f(name, candidates) }
+
+// DefaultSuggester suggests the candidate with the smallest Levenshtein distance from name.
+// It allows one edit for names shorter than six characters and two for longer ones.
+var DefaultSuggester Suggester = SuggesterFunc(func(name string, candidates []string) string {
+	var suggestion string
+	best := 2
+	if len(name) >= 6 {
+		best = 3
+	}
+	for _, candidate := range candidates {
+		if d := Levenshtein(name, candidate); d < best {
+			suggestion, best = candidate, d
+		}
+	}
+	return suggestion
+})
+
+// unknown returns an error if name looks like a mistyped command, suggesting the closest command name with s.
+// If there is no close command name, it is only an error if strict is true, such as when there is no action for name to be an argument of.
+func (cs *CommandSet) unknown(name string, strict bool, s Suggester) error {
+	if s == nil {
+		s = DefaultSuggester
+	}
+	if suggestion := s.Suggest(name, cs.candidates()); suggestion != "" {
+		return fmt.Errorf("unknown command %q, did you mean %q?", name, suggestion)
+	} else if strict {
+		return fmt.Errorf("unknown command %q", name)
+	}
+	return nil
+}
+
+// candidates returns the names and visible aliases of the commands that are not deprecated or hidden, for suggesting.
+func (cs *CommandSet) candidates() []string {
+	var candidates []string
+	for _, command := range *cs {
+		if command.isDeprecated() || command.Hidden {
+			continue
+		}
+		candidates = append(candidates, command.Names[0])
+		candidates = append(candidates, command.aliases()...)
+	}
+	return candidates
+}
+
+// Levenshtein returns the edit distance between a and b, counting insertions, deletions and substitutions of characters.
+func Levenshtein(a, b string) int {
+	s, t := []rune(a), []rune(b)
+	prev := make([]int, len(t)+1)
+	curr := make([]int, len(t)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		curr[0] = i
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			curr[j] = min3(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(t)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
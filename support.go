@@ -0,0 +1,120 @@
+package clippy
+
+import (
+	"archive/zip"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SupportCollector adds a file to a support bundle, such as recent logs or the configuration with its secrets redacted.
+type SupportCollector struct {
+	Name    string                 // Name of the file in the bundle. For example, "config.json".
+	Collect func() ([]byte, error) // Collect returns the contents of the file. If it fails, the error is noted in the bundle instead.
+}
+
+// secretWords are the parts of environment variable names whose values are redacted in support bundles.
+var secretWords = []string{"TOKEN", "SECRET", "PASSWORD", "PASSWD", "KEY", "CREDENTIAL", "AUTH", "COOKIE", "SESSION"}
+
+// SupportBundleCommand returns a "support-bundle" command that writes a zip file for users to attach to bug reports.
+// The bundle has the program's version and build information, its environment with the values of secret-looking variables redacted, and a file from each of collectors.
+// It can be added to the program's Commands.
+func (c *Clippy) SupportBundleCommand(collectors ...SupportCollector) *Command {
+	return &Command{
+		Names:       []string{"support-bundle"},
+		Description: "write a zip file of version, build and environment details to attach to bug reports",
+		Flags: FlagSet{
+			{Name: "output", Alias: 'o', Type: "FILENAME", Description: "the file to write the bundle to (default: NAME-support.zip)"},
+		},
+		Action: func(flags Flags, args []string) error {
+			path := flags.GetString("output")
+			if path == "" {
+				path = c.Name + "-support.zip"
+			}
+			if err := c.writeSupportBundle(path, collectors); err != nil {
+				return err
+			}
+			return c.println("wrote support bundle to " + path)
+		},
+	}
+}
+
+// writeSupportBundle writes a support bundle with the files from collectors to path.
+func (c *Clippy) writeSupportBundle(path string, collectors []SupportCollector) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Collect the built-in files, then the program's.
+	files := []SupportCollector{
+		{Name: "version.txt", Collect: func() ([]byte, error) {
+			return []byte(fmt.Sprintf("%s\n%s %s/%s\n", c.version(), runtime.Version(), runtime.GOOS, runtime.GOARCH)), nil
+		}},
+		{Name: "build.json", Collect: func() ([]byte, error) {
+			s, err := c.sbom()
+			return []byte(s), err
+		}},
+		{Name: "environment.txt", Collect: func() ([]byte, error) { return []byte(redactedEnviron()), nil }},
+	}
+	files = append(files, collectors...)
+
+	zw := zip.NewWriter(f)
+	var failures []string
+	for _, file := range files {
+		b, err := file.Collect()
+		if err != nil {
+			failures = append(failures, file.Name+": "+err.Error())
+			continue
+		}
+		if err := writeZipFile(zw, file.Name, b); err != nil {
+			return err
+		}
+	}
+	if len(failures) >= 1 {
+		if err := writeZipFile(zw, "errors.txt", []byte(strings.Join(failures, "\n")+"\n")); err != nil {
+			return err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func writeZipFile(zw *zip.Writer, name string, b []byte) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(b)
+	return err
+}
+
+// redactedEnviron returns the environment, sorted, with the values of variables whose names look secret replaced.
+func redactedEnviron() string {
+	env := os.Environ()
+	sort.Strings(env)
+	var sb strings.Builder
+	for _, kv := range env {
+		if i := strings.IndexRune(kv, '='); i != -1 && isSecretName(kv[:i]) {
+			kv = kv[:i+1] + "[redacted]"
+		}
+		sb.WriteString(kv + "\n")
+	}
+	return sb.String()
+}
+
+func isSecretName(name string) bool {
+	name = strings.ToUpper(name)
+	for _, word := range secretWords {
+		if strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
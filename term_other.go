@@ -0,0 +1,11 @@
+//go:build !linux && !darwin && !freebsd && !netbsd && !openbsd && !dragonfly
+// +build !linux,!darwin,!freebsd,!netbsd,!openbsd,!dragonfly
+
+package clippy
+
+import "os"
+
+// terminalWidth returns zero, since the width of a terminal cannot be found on this OS without other packages.
+func terminalWidth(f *os.File) int {
+	return 0
+}
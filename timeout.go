@@ -0,0 +1,19 @@
+package clippy
+
+import (
+	"fmt"
+	"time"
+)
+
+// timeout removes the "--timeout" global flag from params, returning its value, or def if it was not given.
+func (c *Clippy) timeout(params []string, def time.Duration) ([]string, time.Duration, error) {
+	params, value, err := c.removeValueParam(params, "--timeout")
+	if err != nil || value == "" {
+		return params, def, err
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return nil, 0, fmt.Errorf("invalid timeout: %q", value)
+	}
+	return params, d, nil
+}
@@ -0,0 +1,13 @@
+package clippy
+
+import (
+	"fmt"
+	"time"
+)
+
+// trace reports the time spent in a lifecycle phase that began at start, if the "--trace" global flag was given.
+func (c *Clippy) trace(phase string, start time.Time) {
+	if c.tracing {
+		fmt.Fprintf(c.stderr(), "%s: trace: %s took %v\n", c.Name, phase, time.Since(start))
+	}
+}
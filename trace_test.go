@@ -0,0 +1,57 @@
+package clippy_test
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+	"github.com/patrickmcnamara/clippy/clippytest"
+)
+
+// loggedIn is an Authenticator for a user who is always logged in.
+type loggedIn struct{}
+
+func (loggedIn) Authenticate(ctx context.Context, interactive bool) error { return nil }
+
+func TestTrace(t *testing.T) {
+	app := &clippy.Clippy{
+		Name:          "app",
+		Version:       "1.0.0",
+		Tracing:       true,
+		Authenticator: loggedIn{},
+		Before:        clippy.DefaultAction,
+		After:         clippy.DefaultAction,
+		Commands: clippy.CommandSet{
+			{Names: []string{"deploy"}, RequiresAuth: true, Before: clippy.DefaultAction, After: clippy.DefaultAction, Action: clippy.DefaultAction},
+			{Names: []string{"build"}, Action: clippy.DefaultAction},
+		},
+	}
+
+	tests := []struct {
+		params []string
+		want   []string
+	}{
+		{[]string{"--trace", "deploy"}, []string{"check", "parse", "before app", "authenticate app deploy", "before app deploy", "action", "after app deploy", "after app"}},
+		{[]string{"build", "--trace"}, []string{"check", "parse", "before app", "action", "after app"}},
+		{[]string{"build"}, nil},
+	}
+	phase := regexp.MustCompile(`^app: trace: (.*) took \S+$`)
+	for _, test := range tests {
+		_, stderr, _, err := clippytest.Execute(app, test.params...)
+		if err != nil {
+			t.Errorf("%q: %v", test.params, err)
+			continue
+		}
+		var got []string
+		for _, line := range strings.Split(strings.TrimSpace(stderr), "\n") {
+			if m := phase.FindStringSubmatch(line); m != nil {
+				got = append(got, m[1])
+			}
+		}
+		if strings.Join(got, ", ") != strings.Join(test.want, ", ") {
+			t.Errorf("%q: traced %q, want %q", test.params, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+// Package validate provides ready-made validators for the values of clippy flags, for use as a Flag's Validate.
+// They can also be called on arguments in an action.
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// All returns a validator that runs each of validators in order, returning the first error.
+func All(validators ...func(value string) error) func(value string) error {
+	return func(value string) error {
+		for _, validator := range validators {
+			if err := validator(value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// NonEmpty checks that value is not empty.
+func NonEmpty(value string) error {
+	if value == "" {
+		return errors.New("must not be empty")
+	}
+	return nil
+}
+
+// OneOf returns a validator that checks that value is one of choices.
+func OneOf(choices ...string) func(value string) error {
+	return func(value string) error {
+		for _, choice := range choices {
+			if value == choice {
+				return nil
+			}
+		}
+		quoted := make([]string, len(choices))
+		for i, choice := range choices {
+			quoted[i] = strconv.Quote(choice)
+		}
+		return fmt.Errorf("%q is not one of %s", value, strings.Join(quoted, ", "))
+	}
+}
+
+// FileExists checks that value is the path of an existing file that is not a directory.
+func FileExists(value string) error {
+	fi, err := os.Stat(value)
+	if err != nil {
+		return fmt.Errorf("file %q does not exist", value)
+	}
+	if fi.IsDir() {
+		return fmt.Errorf("%q is a directory, not a file", value)
+	}
+	return nil
+}
+
+// DirWritable checks that value is the path of an existing directory that files can be written into.
+func DirWritable(value string) error {
+	fi, err := os.Stat(value)
+	if err != nil {
+		return fmt.Errorf("directory %q does not exist", value)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%q is not a directory", value)
+	}
+	f, err := ioutil.TempFile(value, ".clippy-validate-*")
+	if err != nil {
+		return fmt.Errorf("directory %q is not writable", value)
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return nil
+}
+
+// MatchesRegexp returns a validator that checks that value matches the regular expression pattern.
+// It panics if pattern does not compile, like regexp.MustCompile.
+func MatchesRegexp(pattern string) func(value string) error {
+	re := regexp.MustCompile(pattern)
+	return func(value string) error {
+		if !re.MatchString(value) {
+			return fmt.Errorf("%q does not match %q", value, pattern)
+		}
+		return nil
+	}
+}
+
+// IntBetween returns a validator that checks that value is an integer from min to max, inclusive.
+func IntBetween(min, max int) func(value string) error {
+	return func(value string) error {
+		i, err := strconv.ParseInt(value, 0, 0)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		if int(i) < min || int(i) > max {
+			return fmt.Errorf("%d is not between %d and %d", i, min, max)
+		}
+		return nil
+	}
+}
+
+// URLWithScheme returns a validator that checks that value is an absolute URL with a host and one of schemes, such as "https".
+// If no schemes are given, any scheme is allowed.
+func URLWithScheme(schemes ...string) func(value string) error {
+	return func(value string) error {
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%q is not an absolute URL", value)
+		}
+		if len(schemes) == 0 {
+			return nil
+		}
+		for _, scheme := range schemes {
+			if strings.EqualFold(u.Scheme, scheme) {
+				return nil
+			}
+		}
+		return fmt.Errorf("URL %q does not have scheme %s", value, strings.Join(schemes, " or "))
+	}
+}
@@ -0,0 +1,32 @@
+package clippy
+
+import (
+	"fmt"
+	"io"
+)
+
+// WarnHandler is a handler for non-fatal warnings, such as deprecation notices.
+// The w is the program's Stderr, the name is the name of the program and the msg is the warning being handled.
+type WarnHandler func(w io.Writer, name string, msg string)
+
+// WarningHandler handles warnings. By default, it prints them to the program's Stderr.
+var WarningHandler WarnHandler = func(w io.Writer, name string, msg string) {
+	fmt.Fprintln(w, name+": warning: "+msg)
+}
+
+// Warn emits a warning using WarningHandler, unless the "--no-warnings" global flag was given.
+// Every warning is collected, whether suppressed or not, and is returned by Warnings, and by the Warnings of the program this one is mounted in.
+func (c *Clippy) Warn(format string, a ...interface{}) {
+	msg := fmt.Sprintf(format, a...)
+	for p := c; p != nil; p = p.parent {
+		p.warnings = append(p.warnings, msg)
+	}
+	if !c.noWarnings {
+		WarningHandler(c.stderr(), c.Name, msg)
+	}
+}
+
+// Warnings returns the warnings emitted so far by the current or last run.
+func (c *Clippy) Warnings() []string {
+	return c.warnings
+}
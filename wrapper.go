@@ -0,0 +1,87 @@
+package clippy
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// WrapperScript returns a shell script that runs the given command of the program.
+// For example, installed as "app-deploy", it runs "app deploy" with the script's arguments.
+func (c *Clippy) WrapperScript(command string) (string, error) {
+	cmd := c.Commands.get(command)
+	if cmd == nil {
+		return "", fmt.Errorf("unknown command: %q", command)
+	}
+	return "#!/bin/sh\n" + "exec " + Quote(c.Name, cmd.Names[0]) + " \"$@\"\n", nil
+}
+
+// WriteWrappers writes a wrapper for each of the given commands into dir, or for every command if none are given.
+// Wrappers are shell scripts named like "app-deploy", from WrapperScript.
+// If symlink is true, they are instead symlinks to the running executable named like "deploy", for use with Dispatch.
+func (c *Clippy) WriteWrappers(dir string, symlink bool, commands ...string) error {
+	// Default to every command.
+	if len(commands) == 0 {
+		for _, cmd := range c.Commands {
+			commands = append(commands, cmd.Names[0])
+		}
+	}
+
+	var executable string
+	if symlink {
+		var err error
+		if executable, err = os.Executable(); err != nil {
+			return err
+		}
+	}
+
+	for _, command := range commands {
+		cmd := c.Commands.get(command)
+		if cmd == nil {
+			return fmt.Errorf("unknown command: %q", command)
+		}
+
+		// Link to the executable.
+		if symlink {
+			if err := os.Symlink(executable, filepath.Join(dir, cmd.Names[0])); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Otherwise write a script.
+		script, err := c.WrapperScript(command)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, c.Name+"-"+cmd.Names[0]), []byte(script), 0755); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WrappersCommand returns a "wrappers" command that writes wrappers for the program's commands using WriteWrappers.
+// It can be added to the program's Commands.
+func (c *Clippy) WrappersCommand() *Command {
+	return &Command{
+		Names:       []string{"wrappers"},
+		Description: "write wrapper scripts or symlinks for commands",
+		Args:        []string{"COMMANDS..."},
+		Flags: FlagSet{
+			{Name: "dir", Alias: 'd', Type: "DIR", Description: "directory to write wrappers into", DefaultValue: "."},
+			{Name: "mode", Alias: 'm', Type: "MODE", Description: "write \"script\" or \"symlink\" wrappers", DefaultValue: "script"},
+		},
+		Action: func(flags Flags, args []string) error {
+			switch flags.GetString("mode") {
+			case "script":
+				return c.WriteWrappers(flags.GetString("dir"), false, args...)
+			case "symlink":
+				return c.WriteWrappers(flags.GetString("dir"), true, args...)
+			}
+			return fmt.Errorf("invalid wrapper mode: %q", flags.GetString("mode"))
+		},
+	}
+}
@@ -0,0 +1,28 @@
+package clippy_test
+
+import (
+	"testing"
+
+	"github.com/patrickmcnamara/clippy"
+)
+
+func TestWrapperScript(t *testing.T) {
+	tests := []struct {
+		name, command string
+		want          string
+	}{
+		{"app", "deploy", "#!/bin/sh\nexec app deploy \"$@\"\n"},
+		{"my app", "deploy", "#!/bin/sh\nexec 'my app' deploy \"$@\"\n"},
+		{"app", "it's", "#!/bin/sh\nexec app 'it'\\''s' \"$@\"\n"},
+		{"app;rm", "$(x)", "#!/bin/sh\nexec 'app;rm' '$(x)' \"$@\"\n"},
+	}
+	for _, test := range tests {
+		app := &clippy.Clippy{Name: test.name, Version: "1.0.0", Commands: clippy.CommandSet{{Names: []string{test.command}}}}
+		script, err := app.WrapperScript(test.command)
+		if err != nil {
+			t.Errorf("%q %q: %v", test.name, test.command, err)
+		} else if script != test.want {
+			t.Errorf("%q %q: got %q, want %q", test.name, test.command, script, test.want)
+		}
+	}
+}